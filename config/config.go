@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"regexp"
 	"time"
 )
 
@@ -61,6 +62,10 @@ var (
 
 	minSubscriptionBufferSize     = 100
 	defaultSubscriptionBufferSize = 200
+
+	// metricsPrefixRegexp matches strings that can start a prometheus metric
+	// name.
+	metricsPrefixRegexp = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
 )
 
 // Config defines the top level configuration for a CometBFT node
@@ -746,6 +751,22 @@ type MempoolConfig struct {
 	// has existed in the mempool at least TTLNumBlocks number of blocks or if
 	// it's insertion time into the mempool is beyond TTLDuration.
 	TTLNumBlocks int64 `mapstructure:"ttl-num-blocks"`
+
+	// RecheckBudget, if non-zero, defines how long a single recheck pass
+	// should take at most. Passes that take longer are logged and counted in
+	// the mempool metrics but are not interrupted. Only used by the "v2"
+	// mempool.
+	RecheckBudget time.Duration `mapstructure:"recheck-budget"`
+
+	// MaxSeenTxJitter, if non-zero, defines the upper bound of the random
+	// delay before the node tells its peers that it has seen a transaction.
+	// Zero means the default of 100ms. Only used by the "v2" mempool.
+	MaxSeenTxJitter time.Duration `mapstructure:"max-seen-tx-jitter"`
+
+	// DisableSeenTxJitter (default: false) makes the node tell its peers that
+	// it has seen a transaction without any delay. MaxSeenTxJitter is then
+	// ignored. Only used by the "v2" mempool.
+	DisableSeenTxJitter bool `mapstructure:"disable-seen-tx-jitter"`
 }
 
 // DefaultMempoolConfig returns a default configuration for the CometBFT mempool
@@ -798,6 +819,12 @@ func (cfg *MempoolConfig) ValidateBasic() error {
 	if cfg.MaxTxBytes < 0 {
 		return errors.New("max_tx_bytes can't be negative")
 	}
+	if cfg.RecheckBudget < 0 {
+		return errors.New("recheck-budget can't be negative")
+	}
+	if cfg.MaxSeenTxJitter < 0 {
+		return errors.New("max-seen-tx-jitter can't be negative")
+	}
 	return nil
 }
 
@@ -1176,6 +1203,15 @@ type InstrumentationConfig struct {
 	// Instrumentation namespace.
 	Namespace string `mapstructure:"namespace"`
 
+	// MempoolMetricsPrefix, if set, replaces the namespace and subsystem at the
+	// start of every mempool metric name. It is prepended as is, so it must
+	// include any separator (e.g. "cometbft:mempool:").
+	MempoolMetricsPrefix string `mapstructure:"mempool_metrics_prefix"`
+
+	// MempoolDisabledMetrics lists the names of the mempool metrics, without
+	// the namespace and subsystem, that are not exposed.
+	MempoolDisabledMetrics []string `mapstructure:"mempool_disabled_metrics"`
+
 	// InfluxURL is the influxdb url.
 	InfluxURL string `mapstructure:"influx_url"`
 
@@ -1196,14 +1232,15 @@ type InstrumentationConfig struct {
 // reporting.
 func DefaultInstrumentationConfig() *InstrumentationConfig {
 	return &InstrumentationConfig{
-		Prometheus:           false,
-		PrometheusListenAddr: ":26660",
-		MaxOpenConnections:   3,
-		Namespace:            "cometbft",
-		InfluxURL:            "",
-		InfluxOrg:            "celestia",
-		InfluxBucket:         "e2e",
-		InfluxBatchSize:      20,
+		Prometheus:             false,
+		PrometheusListenAddr:   ":26660",
+		MaxOpenConnections:     3,
+		Namespace:              "cometbft",
+		MempoolDisabledMetrics: []string{},
+		InfluxURL:              "",
+		InfluxOrg:              "celestia",
+		InfluxBucket:           "e2e",
+		InfluxBatchSize:        20,
 	}
 }
 
@@ -1219,6 +1256,10 @@ func (cfg *InstrumentationConfig) ValidateBasic() error {
 	if cfg.MaxOpenConnections < 0 {
 		return errors.New("max_open_connections can't be negative")
 	}
+	if cfg.MempoolMetricsPrefix != "" && !metricsPrefixRegexp.MatchString(cfg.MempoolMetricsPrefix) {
+		return fmt.Errorf("mempool_metrics_prefix %q is not a valid prometheus metric name prefix",
+			cfg.MempoolMetricsPrefix)
+	}
 	// if there is not InfluxURL configured, then we do not need to validate the rest
 	// of the config because we are not connecting.
 	if cfg.InfluxURL == "" {
@@ -113,6 +113,8 @@ func TestMempoolConfigValidateBasic(t *testing.T) {
 		"MaxTxsBytes",
 		"CacheSize",
 		"MaxTxBytes",
+		"RecheckBudget",
+		"MaxSeenTxJitter",
 	}
 
 	for _, fieldName := range fieldsToTest {
@@ -189,4 +191,12 @@ func TestInstrumentationConfigValidateBasic(t *testing.T) {
 	// tamper with maximum open connections
 	cfg.MaxOpenConnections = -1
 	assert.Error(t, cfg.ValidateBasic())
+	cfg.MaxOpenConnections = 0
+
+	cfg.MempoolMetricsPrefix = "cometbft:mempool:"
+	assert.NoError(t, cfg.ValidateBasic())
+	cfg.MempoolMetricsPrefix = "cometbft.mempool."
+	assert.Error(t, cfg.ValidateBasic())
+	cfg.MempoolMetricsPrefix = "1mempool_"
+	assert.Error(t, cfg.ValidateBasic())
 }
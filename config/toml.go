@@ -398,6 +398,21 @@ ttl-duration = "{{ .Mempool.TTLDuration }}"
 # it's insertion time into the mempool is beyond ttl-duration.
 ttl-num-blocks = {{ .Mempool.TTLNumBlocks }}
 
+# recheck-budget, if non-zero, defines how long a single recheck pass should
+# take at most. Passes that take longer are logged and counted in the mempool
+# metrics but are not interrupted. Only used by the "v2" mempool.
+recheck-budget = "{{ .Mempool.RecheckBudget }}"
+
+# max-seen-tx-jitter, if non-zero, defines the upper bound of the random delay
+# before the node tells its peers that it has seen a transaction. Zero means
+# the default of 100ms. Only used by the "v2" mempool.
+max-seen-tx-jitter = "{{ .Mempool.MaxSeenTxJitter }}"
+
+# disable-seen-tx-jitter makes the node tell its peers that it has seen a
+# transaction without any delay. max-seen-tx-jitter is then ignored. Only used
+# by the "v2" mempool.
+disable-seen-tx-jitter = {{ .Mempool.DisableSeenTxJitter }}
+
 #######################################################
 ###         State Sync Configuration Options        ###
 #######################################################
@@ -541,6 +556,16 @@ max_open_connections = {{ .Instrumentation.MaxOpenConnections }}
 # Instrumentation namespace
 namespace = "{{ .Instrumentation.Namespace }}"
 
+# If set, replaces the namespace and subsystem at the start of every mempool
+# metric name. It is used as is, so it must include any separator,
+# e.g. "cometbft:mempool:". Only letters, digits, '_' and ':' are allowed.
+mempool_metrics_prefix = "{{ .Instrumentation.MempoolMetricsPrefix }}"
+
+# Names of the mempool metrics, without the namespace and subsystem, that are
+# not exposed, e.g. ["size", "tx_size_bytes"]. Unknown names are logged and
+# otherwise ignored.
+mempool_disabled_metrics = [{{ range .Instrumentation.MempoolDisabledMetrics }}{{ printf "%q, " . }}{{end}}]
+
 # The URL of the influxdb instance to use for remote event 
 # collection. If empty, remote event collection is disabled.
 influx_url = "{{ .Instrumentation.InfluxURL }}"
@@ -203,6 +203,10 @@ func (c *Client) BroadcastTxSync(ctx context.Context, tx types.Tx) (*ctypes.Resu
 	return c.next.BroadcastTxSync(ctx, tx)
 }
 
+func (c *Client) BroadcastTxBatch(ctx context.Context, txs []types.Tx) (*ctypes.ResultBroadcastTxBatch, error) {
+	return c.next.BroadcastTxBatch(ctx, txs)
+}
+
 func (c *Client) UnconfirmedTxs(ctx context.Context, limit *int) (*ctypes.ResultUnconfirmedTxs, error) {
 	return c.next.UnconfirmedTxs(ctx, limit)
 }
@@ -0,0 +1,72 @@
+package cat
+
+import (
+	"fmt"
+
+	abci "github.com/tendermint/tendermint/abci/types"
+)
+
+// appCodes are the application specific CheckTx response codes that the pool
+// recognises in order to count rejections by cause. Each is set with its own
+// TxPoolOption; a code left at zero is never matched. NewTxPool panics if two
+// causes share the same code.
+type appCodes struct {
+	maxMsgsPerTx        uint32
+	unsupportedFeeToken uint32
+	innerDecode         uint32
+	frozenSender        uint32
+	staleStateRef       uint32
+}
+
+// validate returns an error if two causes share the same non-zero code, in
+// which case rejections would silently be counted under only one of them.
+func (c appCodes) validate() error {
+	codes := []struct {
+		option string
+		code   uint32
+	}{
+		{"WithMaxMsgsPerTxCode", c.maxMsgsPerTx},
+		{"WithUnsupportedFeeTokenCode", c.unsupportedFeeToken},
+		{"WithInnerDecodeCode", c.innerDecode},
+		{"WithFrozenSenderCode", c.frozenSender},
+		{"WithStaleStateRefCode", c.staleStateRef},
+	}
+	seen := make(map[uint32]string, len(codes))
+	for _, c := range codes {
+		if c.code == abci.CodeTypeOK {
+			continue
+		}
+		if other, ok := seen[c.code]; ok {
+			return fmt.Errorf("%s and %s both set code %d", other, c.option, c.code)
+		}
+		seen[c.code] = c.option
+	}
+	return nil
+}
+
+// countRejection records the cause of a transaction rejected by the
+// application, if the code of its response is recognised.
+func (txmp *TxPool) countRejection(rsp *abci.ResponseCheckTx) {
+	switch rsp.Code {
+	case abci.CodeTypeOK:
+	case txmp.appCodes.maxMsgsPerTx:
+		txmp.currentMetrics().MaxMsgsPerTxRejects.Add(1)
+	case txmp.appCodes.unsupportedFeeToken:
+		// the application reports the token in the info of the response
+		txmp.currentMetrics().UnsupportedFeeToken.With("token", txmp.tokenLabels.Get(rsp.Info)).Add(1)
+	case txmp.appCodes.innerDecode:
+		txmp.currentMetrics().InnerDecodeFailures.Add(1)
+	}
+}
+
+// countRecheckRemoval records the cause of a transaction removed because it
+// failed recheck, if the code of its response is recognised.
+func (txmp *TxPool) countRecheckRemoval(rsp *abci.ResponseCheckTx) {
+	switch rsp.Code {
+	case abci.CodeTypeOK:
+	case txmp.appCodes.frozenSender:
+		txmp.currentMetrics().FrozenSenderRemovals.Add(1)
+	case txmp.appCodes.staleStateRef:
+		txmp.currentMetrics().StaleStateRefEvictions.Add(1)
+	}
+}
@@ -0,0 +1,63 @@
+package cat
+
+import (
+	tmsync "github.com/tendermint/tendermint/libs/sync"
+)
+
+const (
+	// maxPeerLabels is the maximum number of distinct peer label values a single
+	// metric reports before grouping all further peers under otherLabel.
+	maxPeerLabels = 100
+
+	// maxTokenLabels is the maximum number of distinct fee token label values a
+	// single metric reports.
+	maxTokenLabels = 20
+
+	// maxFormatLabels is the maximum number of distinct tx format label values
+	// a single metric reports.
+	maxFormatLabels = 20
+
+	// maxExperimentLabels is the maximum number of distinct experiment label
+	// values a single metric reports.
+	maxExperimentLabels = 20
+
+	// otherLabel is the label value used once a label has reached its cap.
+	otherLabel = "other"
+)
+
+// cappedLabels bounds the cardinality of a metric label. The first values it
+// sees are passed through unchanged; once the cap is reached every new value
+// is reported as otherLabel.
+type cappedLabels struct {
+	mtx    tmsync.Mutex
+	max    int
+	values map[string]struct{}
+}
+
+func newCappedLabels(max int) *cappedLabels {
+	return &cappedLabels{
+		max:    max,
+		values: make(map[string]struct{}, max),
+	}
+}
+
+// Get returns the label value to report for the given value.
+func (c *cappedLabels) Get(value string) string {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if _, ok := c.values[value]; ok {
+		return value
+	}
+	if len(c.values) >= c.max {
+		return otherLabel
+	}
+	c.values[value] = struct{}{}
+	return value
+}
+
+// Len returns the number of values that are passed through unchanged.
+func (c *cappedLabels) Len() int {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return len(c.values)
+}
@@ -0,0 +1,18 @@
+package cat
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCappedLabels(t *testing.T) {
+	labels := newCappedLabels(2)
+	require.Equal(t, "a", labels.Get("a"))
+	require.Equal(t, "b", labels.Get("b"))
+	require.Equal(t, otherLabel, labels.Get("c"))
+	// values seen before the cap was reached keep their own label
+	require.Equal(t, "a", labels.Get("a"))
+	require.Equal(t, otherLabel, labels.Get("d"))
+	require.Equal(t, 2, labels.Len())
+}
@@ -24,11 +24,18 @@ var _ mempool.Mempool = (*TxPool)(nil)
 var (
 	ErrTxInMempool       = errors.New("tx already exists in mempool")
 	ErrTxAlreadyRejected = errors.New("tx was previously rejected")
+	ErrReadOnly          = errors.New("mempool is in read-only mode")
 )
 
 // TxPoolOption sets an optional parameter on the TxPool.
 type TxPoolOption func(*TxPool)
 
+// namedFilter is a filter installed with WithFilter.
+type namedFilter struct {
+	name string
+	fn   mempool.PreCheckFunc
+}
+
 // TxPool implemements the Mempool interface and allows the application to
 // set priority values on transactions in the CheckTx response. When selecting
 // transactions to include in a block, higher-priority transactions are chosen
@@ -51,7 +58,10 @@ type TxPool struct {
 	logger       log.Logger
 	config       *config.MempoolConfig
 	proxyAppConn proxy.AppConnMempool
-	metrics      *mempool.Metrics
+
+	// metrics can be swapped at runtime with SetMetrics.
+	metricsMtx sync.RWMutex
+	metrics    *mempool.Metrics
 
 	// these values are modified once per height
 	updateMtx            sync.Mutex
@@ -59,12 +69,44 @@ type TxPool struct {
 	txsAvailable         chan struct{} // one value sent per height when mempool is not empty
 	preCheckFn           mempool.PreCheckFunc
 	postCheckFn          mempool.PostCheckFunc
+	filters              []namedFilter
+	classifyFormat       func(types.Tx) string
+	capacityWatermark    float64
+	fastPathThreshold    time.Duration
+	appCodes             appCodes
 	height               int64 // the latest height passed to Update
+	ttlDuration          time.Duration
+	ttlNumBlocks         int64
 
 	// Thread-safe cache of rejected transactions for quick look-up
 	rejectedTxCache *LRUTxCache
+	// Thread-safe cache of recently committed transactions. These are also in
+	// the rejectedTxCache.
+	committedTxCache *LRUTxCache
+	// Thread-safe cache of transactions that were in the pool but were evicted,
+	// expired or removed on recheck
+	droppedTxCache *LRUTxCache
 	// Thread-safe list of transactions peers have seen that we have not yet seen
 	seenByPeersSet *SeenTxSet
+	// Thread-safe sets of peer, fee token, tx format and experiment label
+	// values reported by metrics
+	peerLabels       *cappedLabels
+	tokenLabels      *cappedLabels
+	formatLabels     *cappedLabels
+	experimentLabels *cappedLabels
+
+	// reapedTxs are the keys of the transactions selected by the last call to
+	// ReapMaxBytesMaxGas. It is cleared on Update.
+	reapedMtx sync.Mutex
+	reapedTxs map[types.TxKey]struct{}
+
+	// experiment labels the admission metrics. It is empty by default.
+	experimentMtx sync.Mutex
+	experiment    string
+
+	// readOnly rejects all new transactions while set.
+	readOnlyMtx sync.Mutex
+	readOnly    bool
 
 	// Store of wrapped transactions
 	store *store
@@ -91,8 +133,17 @@ func NewTxPool(
 		proxyAppConn:     proxyAppConn,
 		metrics:          mempool.NopMetrics(),
 		rejectedTxCache:  NewLRUTxCache(cfg.CacheSize),
+		committedTxCache: NewLRUTxCache(cfg.CacheSize),
+		droppedTxCache:   NewLRUTxCache(cfg.CacheSize),
 		seenByPeersSet:   NewSeenTxSet(),
+		peerLabels:       newCappedLabels(maxPeerLabels),
+		tokenLabels:      newCappedLabels(maxTokenLabels),
+		formatLabels:     newCappedLabels(maxFormatLabels),
+		experimentLabels: newCappedLabels(maxExperimentLabels),
+		reapedTxs:        make(map[types.TxKey]struct{}),
 		height:           height,
+		ttlDuration:      cfg.TTLDuration,
+		ttlNumBlocks:     cfg.TTLNumBlocks,
 		preCheckFn:       func(_ types.Tx) error { return nil },
 		postCheckFn:      func(_ types.Tx, _ *abci.ResponseCheckTx) error { return nil },
 		store:            newStore(),
@@ -103,6 +154,15 @@ func NewTxPool(
 	for _, opt := range options {
 		opt(txmp)
 	}
+	if err := txmp.appCodes.validate(); err != nil {
+		panic(fmt.Sprintf("mempool: invalid application codes: %v", err))
+	}
+	if w := txmp.capacityWatermark; w != 0 && !(w > 0 && w < 1) {
+		txmp.logger.Error("ignoring capacity watermark outside of (0, 1)", "watermark", w)
+		txmp.capacityWatermark = 0
+	}
+	txmp.currentMetrics().TTLSeconds.Set(txmp.ttlDuration.Seconds())
+	txmp.currentMetrics().TTLBlocks.Set(float64(txmp.ttlNumBlocks))
 
 	return txmp
 }
@@ -126,6 +186,68 @@ func WithMetrics(metrics *mempool.Metrics) TxPoolOption {
 	return func(txmp *TxPool) { txmp.metrics = metrics }
 }
 
+// WithFilter adds a filter that rejects a transaction if f(tx) returns an
+// error. Filters run in the order they were added, before the pre-check and
+// CheckTx. Unlike the pre-check, they are not replaced on Update. The name
+// identifies the filter in the metrics.
+func WithFilter(name string, f mempool.PreCheckFunc) TxPoolOption {
+	return func(txmp *TxPool) { txmp.filters = append(txmp.filters, namedFilter{name, f}) }
+}
+
+// WithTxFormatClassifier sets a function that reports the format of a
+// transaction, e.g. its encoding version. Admitted transactions are counted by
+// format, which lets operators follow the adoption of a new format.
+func WithTxFormatClassifier(f func(types.Tx) string) TxPoolOption {
+	return func(txmp *TxPool) { txmp.classifyFormat = f }
+}
+
+// WithCapacityWatermark sets the fraction of the mempool's capacity, in either
+// the number of transactions or their total size, beyond which admissions are
+// counted as near capacity. It must be between 0 and 1, exclusive; other values
+// are ignored. Zero, the default, disables the count.
+func WithCapacityWatermark(fraction float64) TxPoolOption {
+	return func(txmp *TxPool) { txmp.capacityWatermark = fraction }
+}
+
+// WithFastPathThreshold sets the time in the mempool below which a transaction
+// reaped for a proposal is counted as a fast path inclusion. Zero, the
+// default, disables the count.
+func WithFastPathThreshold(threshold time.Duration) TxPoolOption {
+	return func(txmp *TxPool) { txmp.fastPathThreshold = threshold }
+}
+
+// WithFrozenSenderCode sets the CheckTx code with which the application
+// rejects transactions on recheck because their sender was frozen.
+func WithFrozenSenderCode(code uint32) TxPoolOption {
+	return func(txmp *TxPool) { txmp.appCodes.frozenSender = code }
+}
+
+// WithInnerDecodeCode sets the CheckTx code with which the application
+// rejects transactions whose inner messages can't be decoded.
+func WithInnerDecodeCode(code uint32) TxPoolOption {
+	return func(txmp *TxPool) { txmp.appCodes.innerDecode = code }
+}
+
+// WithMaxMsgsPerTxCode sets the CheckTx code with which the application
+// rejects transactions that contain too many messages.
+func WithMaxMsgsPerTxCode(code uint32) TxPoolOption {
+	return func(txmp *TxPool) { txmp.appCodes.maxMsgsPerTx = code }
+}
+
+// WithStaleStateRefCode sets the CheckTx code with which the application
+// rejects transactions on recheck because the state they reference, e.g. a
+// block hash, was pruned.
+func WithStaleStateRefCode(code uint32) TxPoolOption {
+	return func(txmp *TxPool) { txmp.appCodes.staleStateRef = code }
+}
+
+// WithUnsupportedFeeTokenCode sets the CheckTx code with which the application
+// rejects transactions that pay fees in an unsupported token. The application
+// is expected to report the token in the Info field of the response.
+func WithUnsupportedFeeTokenCode(code uint32) TxPoolOption {
+	return func(txmp *TxPool) { txmp.appCodes.unsupportedFeeToken = code }
+}
+
 // Lock is a noop as ABCI calls are serialized
 func (txmp *TxPool) Lock() {}
 
@@ -190,9 +312,74 @@ func (txmp *TxPool) IsRejectedTx(txKey types.TxKey) bool {
 // application's ABCI CheckTx method. This should be viewed as the entry method for new transactions
 // into the network. In practice this happens via an RPC endpoint
 func (txmp *TxPool) CheckTx(tx types.Tx, cb func(*abci.Response), txInfo mempool.TxInfo) error {
+	rsp, err := txmp.checkTx(tx, txInfo)
+	if err != nil {
+		return err
+	}
+	// call the callback if it is set
+	if cb != nil {
+		cb(&abci.Response{Value: &abci.Response_CheckTx{CheckTx: rsp}})
+	}
+	return nil
+}
+
+// CheckTxBatch runs CheckTx for each of the txs in order and returns their
+// responses in the same order. Valid txs are admitted even if others in the
+// batch are rejected. Once the mempool is full, the remaining txs are not
+// checked but are still given a response. Txs refused by the mempool rather
+// than the application get a response in the mempool.Codespace. So do
+// repetitions of a tx that the batch already added.
+func (txmp *TxPool) CheckTxBatch(txs types.Txs, txInfo mempool.TxInfo) []*abci.ResponseCheckTx {
+	responses := make([]*abci.ResponseCheckTx, len(txs))
+	added := make(map[types.TxKey]struct{}, len(txs))
+	var errFull error
+	for i, tx := range txs {
+		if errFull != nil {
+			responses[i] = rejectedResponse(errFull)
+			continue
+		}
+		key := tx.Key()
+		if _, ok := added[key]; ok {
+			txmp.currentMetrics().BatchDuplicateRejects.Add(1)
+			responses[i] = rejectedResponse(ErrTxInMempool)
+			continue
+		}
+		rsp, err := txmp.checkTx(tx, txInfo)
+		if errors.As(err, &mempool.ErrMempoolIsFull{}) {
+			errFull = err
+		}
+		switch {
+		case err == nil:
+			added[key] = struct{}{}
+			responses[i] = rsp
+		// the application rejected the transaction
+		case rsp != nil && rsp.Code != abci.CodeTypeOK:
+			responses[i] = rsp
+		default:
+			responses[i] = rejectedResponse(err)
+		}
+	}
+	return responses
+}
+
+// rejectedResponse returns the response for a transaction that the mempool
+// refused with the given error.
+func rejectedResponse(err error) *abci.ResponseCheckTx {
+	return &abci.ResponseCheckTx{
+		Code:         mempool.CodeTypeRejected,
+		Codespace:    mempool.Codespace,
+		Log:          err.Error(),
+		MempoolError: err.Error(),
+	}
+}
+
+// checkTx verifies a new transaction against the application and attempts to
+// add it to the pool. The response of the application is returned even if
+// the transaction was rejected.
+func (txmp *TxPool) checkTx(tx types.Tx, txInfo mempool.TxInfo) (*abci.ResponseCheckTx, error) {
 	// Reject transactions in excess of the configured maximum transaction size.
 	if len(tx) > txmp.config.MaxTxBytes {
-		return mempool.ErrTxTooLarge{Max: txmp.config.MaxTxBytes, Actual: len(tx)}
+		return nil, mempool.ErrTxTooLarge{Max: txmp.config.MaxTxBytes, Actual: len(tx)}
 	}
 
 	// This is a new transaction that we haven't seen before. Verify it against the app and attempt
@@ -200,18 +387,12 @@ func (txmp *TxPool) CheckTx(tx types.Tx, cb func(*abci.Response), txInfo mempool
 	key := tx.Key()
 	rsp, err := txmp.TryAddNewTx(tx, key, txInfo)
 	if err != nil {
-		return err
+		return rsp, err
 	}
-	defer func() {
-		// call the callback if it is set
-		if cb != nil {
-			cb(&abci.Response{Value: &abci.Response_CheckTx{CheckTx: rsp}})
-		}
-	}()
 
 	// push to the broadcast queue that a new transaction is ready
 	txmp.markToBeBroadcast(key)
-	return nil
+	return rsp, nil
 }
 
 // next is used by the reactor to get the next transaction to broadcast
@@ -267,28 +448,62 @@ func (txmp *TxPool) TryAddNewTx(tx types.Tx, key types.TxKey, txInfo mempool.TxI
 	// - If a client submits a transaction to multiple nodes (via RPC)
 	// - We send multiple requests and the first peer eventually responds after the second peer has already provided the tx
 	if txmp.IsRejectedTx(key) {
+		// Committed txs are pushed to the rejected cache too, but resubmitting
+		// one says nothing about the tx being bad.
+		if txmp.committedTxCache.Has(key) {
+			txmp.currentMetrics().RecentlyCommittedHits.Add(1)
+		} else {
+			txmp.currentMetrics().NegativeCacheHits.Add(1)
+		}
 		// The peer has sent us a transaction that we have previously marked as invalid. Since `CheckTx` can
 		// be non-deterministic, we don't punish the peer but instead just ignore the tx
 		return nil, ErrTxAlreadyRejected
 	}
 
+	// The same transaction is being checked concurrently.
+	if txmp.store.reserved(key) {
+		txmp.currentMetrics().InFlightDuplicateRejects.Add(1)
+		txmp.PeerHasTx(txInfo.SenderID, key)
+		return nil, ErrTxInMempool
+	}
+
 	if txmp.Has(key) {
-		txmp.metrics.AlreadySeenTxs.Add(1)
+		txmp.currentMetrics().AlreadySeenTxs.Add(1)
+		if txmp.isReaped(key) {
+			txmp.currentMetrics().DuplicateOfReapedTx.Add(1)
+		}
 		// The peer has sent us a transaction that we have already seen
 		return nil, ErrTxInMempool
 	}
 
+	if txmp.isReadOnly() {
+		txmp.currentMetrics().ReadOnlyModeRejects.Add(1)
+		return nil, ErrReadOnly
+	}
+
 	// reserve the key
 	if !txmp.store.reserve(key) {
 		txmp.logger.Debug("mempool already attempting to verify and add transaction", "txKey", fmt.Sprintf("%X", key))
+		txmp.currentMetrics().InFlightDuplicateRejects.Add(1)
 		txmp.PeerHasTx(txInfo.SenderID, key)
 		return nil, ErrTxInMempool
 	}
 	defer txmp.store.release(key)
 
+	for _, filter := range txmp.filters {
+		if err := filter.fn(tx); err != nil {
+			txmp.currentMetrics().PluginFilterRejects.With("filter", filter.name).Add(1)
+			txmp.currentMetrics().FailedTxs.Add(1)
+			return nil, mempool.ErrPreCheck{Reason: err}
+		}
+	}
+
 	// If a precheck hook is defined, call it before invoking the application.
 	if err := txmp.preCheck(tx); err != nil {
-		txmp.metrics.FailedTxs.Add(1)
+		if errors.Is(err, mempool.ErrUnsupportedTxVersion) {
+			txmp.currentMetrics().UnsupportedTxVersion.Add(1)
+		}
+		txmp.currentMetrics().FailedTxs.Add(1)
 		return nil, mempool.ErrPreCheck{Reason: err}
 	}
 
@@ -306,7 +521,8 @@ func (txmp *TxPool) TryAddNewTx(tx types.Tx, key types.TxKey, txInfo mempool.TxI
 		if txmp.config.KeepInvalidTxsInCache {
 			txmp.rejectedTxCache.Push(key)
 		}
-		txmp.metrics.FailedTxs.Add(1)
+		txmp.countRejection(rsp)
+		txmp.currentMetrics().FailedTxs.Add(1)
 		return rsp, fmt.Errorf("application rejected transaction with code %d (Log: %s)", rsp.Code, rsp.Log)
 	}
 
@@ -314,6 +530,7 @@ func (txmp *TxPool) TryAddNewTx(tx types.Tx, key types.TxKey, txInfo mempool.TxI
 	wtx := newWrappedTx(
 		tx, key, txmp.Height(), rsp.GasWanted, rsp.Priority, rsp.Sender,
 	)
+	wtx.peer = txInfo.SenderP2PID
 
 	// Perform the post check
 	err = txmp.postCheck(wtx.tx, rsp)
@@ -321,7 +538,7 @@ func (txmp *TxPool) TryAddNewTx(tx types.Tx, key types.TxKey, txInfo mempool.TxI
 		if txmp.config.KeepInvalidTxsInCache {
 			txmp.rejectedTxCache.Push(key)
 		}
-		txmp.metrics.FailedTxs.Add(1)
+		txmp.currentMetrics().FailedTxs.Add(1)
 		return rsp, fmt.Errorf("rejected bad transaction after post check: %w", err)
 	}
 
@@ -337,7 +554,7 @@ func (txmp *TxPool) TryAddNewTx(tx types.Tx, key types.TxKey, txInfo mempool.TxI
 // mempool. It adds it to the rejectedTxCache so it will not be added again
 func (txmp *TxPool) RemoveTxByKey(txKey types.TxKey) error {
 	txmp.removeTxByKey(txKey)
-	txmp.metrics.EvictedTxs.Add(1)
+	txmp.currentMetrics().EvictedTxs.Add(1)
 	return nil
 }
 
@@ -347,6 +564,22 @@ func (txmp *TxPool) removeTxByKey(txKey types.TxKey) {
 	txmp.seenByPeersSet.RemoveKey(txKey)
 }
 
+// checkCommittedTx verifies that a committed transaction has left the mempool
+// and, if the cache is enabled, is in the cache so that it won't be added
+// again.
+func (txmp *TxPool) checkCommittedTx(txKey types.TxKey) {
+	// a reserved placeholder means the tx is being checked again concurrently,
+	// which is rejected later by the cache, so only a real entry counts
+	wtx := txmp.store.get(txKey)
+	resident := wtx != nil && wtx.height != -1
+	cached := txmp.config.CacheSize == 0 || txmp.rejectedTxCache.Has(txKey)
+	if resident || !cached {
+		txmp.logger.Error("inconsistent state after removing committed tx",
+			"txKey", txKey, "resident", resident, "cached", cached)
+		txmp.currentMetrics().CacheConsistencyViolations.Add(1)
+	}
+}
+
 // Flush purges the contents of the mempool and the cache, leaving both empty.
 // The current height is not modified by this operation.
 func (txmp *TxPool) Flush() {
@@ -356,7 +589,9 @@ func (txmp *TxPool) Flush() {
 	txmp.store.reset()
 	txmp.seenByPeersSet.Reset()
 	txmp.rejectedTxCache.Reset()
-	txmp.metrics.EvictedTxs.Add(float64(size))
+	txmp.committedTxCache.Reset()
+	txmp.droppedTxCache.Reset()
+	txmp.currentMetrics().EvictedTxs.Add(float64(size))
 	txmp.broadcastMtx.Lock()
 	defer txmp.broadcastMtx.Unlock()
 	txmp.txsToBeBroadcast = make([]types.TxKey, 0)
@@ -396,6 +631,10 @@ func (txmp *TxPool) ReapMaxBytesMaxGas(maxBytes, maxGas int64) types.Txs {
 	var totalGas, totalBytes int64
 
 	var keep []types.Tx //nolint:prealloc
+	reaped := make(map[types.TxKey]struct{})
+	prioritized := false
+	fastPath := 0
+	now := time.Now()
 	for _, w := range txmp.allEntriesSorted() {
 		// N.B. When computing byte size, we need to include the overhead for
 		// encoding as protobuf to send to the application.
@@ -405,6 +644,19 @@ func (txmp *TxPool) ReapMaxBytesMaxGas(maxBytes, maxGas int64) types.Txs {
 			break
 		}
 		keep = append(keep, w.tx)
+		reaped[w.key] = struct{}{}
+		prioritized = prioritized || w.priority != 0
+		// a tx reaped again for a later proposal was already counted
+		if now.Sub(w.timestamp) < txmp.fastPathThreshold && w.fastPathCounted.CompareAndSwap(false, true) {
+			fastPath++
+		}
+	}
+	txmp.setReaped(reaped)
+	txmp.currentMetrics().FastPathInclusions.Add(float64(fastPath))
+	txmp.currentMetrics().ReapBatchSize.Observe(float64(len(keep)))
+	// without priorities the txs are only ordered by their arrival
+	if len(keep) > 1 && !prioritized {
+		txmp.currentMetrics().FIFOFallbacks.Add(1)
 	}
 	return keep
 }
@@ -466,10 +718,26 @@ func (txmp *TxPool) Update(
 	}
 	txmp.updateMtx.Unlock()
 
-	txmp.metrics.SuccessfulTxs.Add(float64(len(blockTxs)))
+	// the proposal that any reaped txs were selected for is now finished
+	txmp.setReaped(make(map[types.TxKey]struct{}))
+
+	txmp.currentMetrics().SuccessfulTxs.Add(float64(len(blockTxs)))
+	residentTxs := 0
 	for _, tx := range blockTxs {
+		key := tx.Key()
+		if wtx := txmp.store.get(key); wtx != nil {
+			residentTxs++
+			if wtx.peer != "" {
+				txmp.currentMetrics().FirstSeenByPeer.With("peer_id", txmp.peerLabels.Get(string(wtx.peer))).Add(1)
+			}
+		}
 		// Regardless of success, remove the transaction from the mempool.
-		txmp.removeTxByKey(tx.Key())
+		txmp.removeTxByKey(key)
+		txmp.committedTxCache.Push(key)
+		txmp.checkCommittedTx(key)
+	}
+	if residentTxs == 0 {
+		txmp.currentMetrics().EmptyUpdates.Add(1)
 	}
 
 	txmp.purgeExpiredTxs(blockHeight)
@@ -478,7 +746,7 @@ func (txmp *TxPool) Update(
 	// initiate re-CheckTx per remaining transaction or notify that remaining
 	// transactions are left.
 	size := txmp.Size()
-	txmp.metrics.Size.Set(float64(size))
+	txmp.currentMetrics().Size.Set(float64(size))
 	if size > 0 {
 		if txmp.config.Recheck {
 			txmp.recheckTransactions()
@@ -489,6 +757,114 @@ func (txmp *TxPool) Update(
 	return nil
 }
 
+// SetMetrics replaces the mempool's metrics collector. It is safe to call
+// while the mempool is in use: every recording goes to either the old or the
+// new collector. The gauges are carried over to the new collector.
+func (txmp *TxPool) SetMetrics(metrics *mempool.Metrics) {
+	txmp.metricsMtx.Lock()
+	txmp.metrics = metrics
+	txmp.metricsMtx.Unlock()
+	txmp.publishGauges(metrics)
+}
+
+// publishGauges sets every gauge owned by the mempool from its current state.
+// New gauges must be added here so that SetMetrics carries them over.
+func (txmp *TxPool) publishGauges(metrics *mempool.Metrics) {
+	ttlDuration, ttlNumBlocks := txmp.ttl()
+	metrics.TTLSeconds.Set(ttlDuration.Seconds())
+	metrics.TTLBlocks.Set(float64(ttlNumBlocks))
+	metrics.Size.Set(float64(txmp.Size()))
+	txmp.reapedMtx.Lock()
+	metrics.ProposalPinnedTxs.Set(float64(len(txmp.reapedTxs)))
+	txmp.reapedMtx.Unlock()
+	if txmp.isReadOnly() {
+		metrics.ReadOnly.Set(1)
+	} else {
+		metrics.ReadOnly.Set(0)
+	}
+	metrics.ObservedTxFormats.Set(float64(txmp.formatLabels.Len()))
+}
+
+func (txmp *TxPool) currentMetrics() *mempool.Metrics {
+	txmp.metricsMtx.RLock()
+	defer txmp.metricsMtx.RUnlock()
+	return txmp.metrics
+}
+
+// SetTTL replaces the age and the number of blocks after which transactions
+// expire from the mempool. A zero value disables the respective limit. The new
+// limits are applied on the next Update.
+func (txmp *TxPool) SetTTL(duration time.Duration, numBlocks int64) {
+	txmp.updateMtx.Lock()
+	defer txmp.updateMtx.Unlock()
+	txmp.ttlDuration = duration
+	txmp.ttlNumBlocks = numBlocks
+	txmp.currentMetrics().TTLSeconds.Set(duration.Seconds())
+	txmp.currentMetrics().TTLBlocks.Set(float64(numBlocks))
+}
+
+func (txmp *TxPool) ttl() (time.Duration, int64) {
+	txmp.updateMtx.Lock()
+	defer txmp.updateMtx.Unlock()
+	return txmp.ttlDuration, txmp.ttlNumBlocks
+}
+
+// SetReadOnly puts the mempool in or out of read-only mode. In read-only mode
+// every new transaction is rejected with ErrReadOnly, while the transactions
+// already in the mempool are still reaped, updated and rechecked as usual.
+func (txmp *TxPool) SetReadOnly(readOnly bool) {
+	txmp.readOnlyMtx.Lock()
+	defer txmp.readOnlyMtx.Unlock()
+	txmp.readOnly = readOnly
+	if readOnly {
+		txmp.currentMetrics().ReadOnly.Set(1)
+	} else {
+		txmp.currentMetrics().ReadOnly.Set(0)
+	}
+}
+
+func (txmp *TxPool) isReadOnly() bool {
+	txmp.readOnlyMtx.Lock()
+	defer txmp.readOnlyMtx.Unlock()
+	return txmp.readOnly
+}
+
+// SetExperimentLabel sets the label with which the transactions admitted from
+// now on are counted. It allows operators to compare throughput across the
+// conditions of an experiment. An empty label ends the experiment. Once
+// maxExperimentLabels labels have been set, new ones are reported as
+// otherLabel.
+func (txmp *TxPool) SetExperimentLabel(label string) {
+	if label != "" {
+		label = txmp.experimentLabels.Get(label)
+	}
+	txmp.experimentMtx.Lock()
+	defer txmp.experimentMtx.Unlock()
+	txmp.experiment = label
+}
+
+func (txmp *TxPool) experimentLabel() string {
+	txmp.experimentMtx.Lock()
+	defer txmp.experimentMtx.Unlock()
+	return txmp.experiment
+}
+
+// setReaped replaces the set of transactions reaped for the current proposal.
+func (txmp *TxPool) setReaped(keys map[types.TxKey]struct{}) {
+	txmp.reapedMtx.Lock()
+	defer txmp.reapedMtx.Unlock()
+	txmp.reapedTxs = keys
+	txmp.currentMetrics().ProposalPinnedTxs.Set(float64(len(keys)))
+}
+
+// isReaped returns true if the transaction was reaped for the current proposal.
+func (txmp *TxPool) isReaped(key types.TxKey) bool {
+	txmp.reapedMtx.Lock()
+	defer txmp.reapedMtx.Unlock()
+	_, ok := txmp.reapedTxs[key]
+	return ok
+}
+
 // addNewTransaction handles the ABCI CheckTx response for the first time a
 // transaction is added to the mempool.  A recheck after a block is committed
 // goes to handleRecheckResult.
@@ -515,11 +891,15 @@ func (txmp *TxPool) addNewTransaction(wtx *wrappedTx, checkTxRes *abci.ResponseC
 		// those candidates is not enough to make room for the new transaction,
 		// drop the new one.
 		if len(victims) == 0 || victimBytes < wtx.size() {
-			txmp.metrics.EvictedTxs.Add(1)
+			txmp.currentMetrics().EvictedTxs.Add(1)
 			checkTxRes.MempoolError = fmt.Sprintf("rejected valid incoming transaction; mempool is full (%X)",
 				wtx.key)
-			return fmt.Errorf("rejected valid incoming transaction; mempool is full (%X). Size: (%d:%d)",
-				wtx.key.String(), txmp.Size(), txmp.SizeBytes())
+			return fmt.Errorf("rejected valid incoming transaction (%X): %w", wtx.key, mempool.ErrMempoolIsFull{
+				NumTxs:      txmp.Size(),
+				MaxTxs:      txmp.config.Size,
+				TxsBytes:    txmp.SizeBytes(),
+				MaxTxsBytes: txmp.config.MaxTxsBytes,
+			})
 		}
 
 		txmp.logger.Debug("evicting lower-priority transactions",
@@ -540,8 +920,10 @@ func (txmp *TxPool) addNewTransaction(wtx *wrappedTx, checkTxRes *abci.ResponseC
 
 		// Evict as many of the victims as necessary to make room.
 		availableBytes := txmp.availableBytes()
+		evicted := 0
 		for _, tx := range victims {
 			txmp.evictTx(tx)
+			evicted++
 
 			// We may not need to evict all the eligible transactions.  Bail out
 			// early if we have made enough room.
@@ -550,12 +932,28 @@ func (txmp *TxPool) addNewTransaction(wtx *wrappedTx, checkTxRes *abci.ResponseC
 				break
 			}
 		}
+		txmp.currentMetrics().EvictedPerAdmission.Observe(float64(evicted))
+		if evicted > 1 {
+			txmp.currentMetrics().EvictionCascades.Add(1)
+		}
 	}
 
+	if txmp.aboveWatermark(wtx) {
+		txmp.currentMetrics().AdmittedNearCapacity.Add(1)
+	}
 	txmp.store.set(wtx)
 
-	txmp.metrics.TxSizeBytes.Observe(float64(wtx.size()))
-	txmp.metrics.Size.Set(float64(txmp.Size()))
+	if wtx.sender == "" {
+		txmp.currentMetrics().UnknownSenderTxs.Add(1)
+	}
+	txmp.currentMetrics().AdmittedTxs.With("experiment", txmp.experimentLabel()).Add(1)
+	if txmp.classifyFormat != nil {
+		format := txmp.formatLabels.Get(txmp.classifyFormat(wtx.tx))
+		txmp.currentMetrics().TxsByFormat.With("format", format).Add(1)
+		txmp.currentMetrics().ObservedTxFormats.Set(float64(txmp.formatLabels.Len()))
+	}
+	txmp.currentMetrics().TxSizeBytes.Observe(float64(wtx.size()))
+	txmp.currentMetrics().Size.Set(float64(txmp.Size()))
 	txmp.logger.Debug(
 		"inserted new valid transaction",
 		"priority", wtx.priority,
@@ -569,7 +967,8 @@ func (txmp *TxPool) addNewTransaction(wtx *wrappedTx, checkTxRes *abci.ResponseC
 
 func (txmp *TxPool) evictTx(wtx *wrappedTx) {
 	txmp.store.remove(wtx.key)
-	txmp.metrics.EvictedTxs.Add(1)
+	txmp.droppedTxCache.Push(wtx.key)
+	txmp.currentMetrics().EvictedTxs.Add(1)
 	txmp.logger.Debug(
 		"evicted valid existing transaction; mempool full",
 		"old_tx", fmt.Sprintf("%X", wtx.key),
@@ -584,7 +983,7 @@ func (txmp *TxPool) evictTx(wtx *wrappedTx) {
 // This method is NOT executed for the initial CheckTx on a new transaction;
 // that case is handled by addNewTransaction instead.
 func (txmp *TxPool) handleRecheckResult(wtx *wrappedTx, checkTxRes *abci.ResponseCheckTx) {
-	txmp.metrics.RecheckTimes.Add(1)
+	txmp.currentMetrics().RecheckTimes.Add(1)
 
 	// If a postcheck hook is defined, call it before checking the result.
 	err := txmp.postCheck(wtx.tx, checkTxRes)
@@ -603,11 +1002,13 @@ func (txmp *TxPool) handleRecheckResult(wtx *wrappedTx, checkTxRes *abci.Respons
 		"code", checkTxRes.Code,
 	)
 	txmp.store.remove(wtx.key)
+	txmp.droppedTxCache.Push(wtx.key)
 	if txmp.config.KeepInvalidTxsInCache {
 		txmp.rejectedTxCache.Push(wtx.key)
 	}
-	txmp.metrics.FailedTxs.Add(1)
-	txmp.metrics.Size.Set(float64(txmp.Size()))
+	txmp.countRecheckRemoval(checkTxRes)
+	txmp.currentMetrics().FailedTxs.Add(1)
+	txmp.currentMetrics().Size.Set(float64(txmp.Size()))
 }
 
 // recheckTransactions initiates re-CheckTx ABCI calls for all the transactions
@@ -633,6 +1034,7 @@ func (txmp *TxPool) recheckTransactions() {
 	// rechecks are complete signal watchers that transactions may be available.
 	go func() {
 		g, start := taskgroup.New(nil).Limit(2 * runtime.NumCPU())
+		startTime := time.Now()
 
 		for _, wtx := range wtxs {
 			wtx := wtx
@@ -655,6 +1057,14 @@ func (txmp *TxPool) recheckTransactions() {
 
 		// When recheck is complete, trigger a notification for more transactions.
 		_ = g.Wait()
+		if elapsed := time.Since(startTime); txmp.config.RecheckBudget > 0 && elapsed > txmp.config.RecheckBudget {
+			txmp.logger.Info("recheck exceeded its budget",
+				"num_txs", len(wtxs),
+				"duration", elapsed,
+				"budget", txmp.config.RecheckBudget,
+			)
+			txmp.currentMetrics().RecheckBudgetExceeded.Add(1)
+		}
 		txmp.notifyTxsAvailable()
 	}()
 }
@@ -678,32 +1088,48 @@ func (txmp *TxPool) canAddTx(size int64) bool {
 	return true
 }
 
+// aboveWatermark returns true if either the number or the total size of the
+// transactions in the mempool, once wtx is added, is beyond the capacity
+// watermark. Other transactions that are still being checked are left out.
+func (txmp *TxPool) aboveWatermark(wtx *wrappedTx) bool {
+	if txmp.capacityWatermark == 0 {
+		return false
+	}
+	return float64(txmp.store.residentSize()+1) > txmp.capacityWatermark*float64(txmp.config.Size) ||
+		float64(txmp.SizeBytes()+wtx.size()) > txmp.capacityWatermark*float64(txmp.config.MaxTxsBytes)
+}
+
 // purgeExpiredTxs removes all transactions from the mempool that have exceeded
 // their respective height or time-based limits as of the given blockHeight.
 // Transactions removed by this operation are not removed from the rejectedTxCache.
 //
 // The caller must hold txmp.mtx exclusively.
 func (txmp *TxPool) purgeExpiredTxs(blockHeight int64) {
-	if txmp.config.TTLNumBlocks == 0 && txmp.config.TTLDuration == 0 {
+	ttlDuration, ttlNumBlocks := txmp.ttl()
+	if ttlNumBlocks == 0 && ttlDuration == 0 {
 		return // nothing to do
 	}
 
-	expirationHeight := blockHeight - txmp.config.TTLNumBlocks
-	if txmp.config.TTLNumBlocks == 0 {
+	expirationHeight := blockHeight - ttlNumBlocks
+	if ttlNumBlocks == 0 {
 		expirationHeight = 0
 	}
 
 	now := time.Now()
-	expirationAge := now.Add(-txmp.config.TTLDuration)
-	if txmp.config.TTLDuration == 0 {
+	expirationAge := now.Add(-ttlDuration)
+	if ttlDuration == 0 {
 		expirationAge = time.Time{}
 	}
 
-	numExpired := txmp.store.purgeExpiredTxs(expirationHeight, expirationAge)
-	txmp.metrics.EvictedTxs.Add(float64(numExpired))
+	purged, byHeight, byAge := txmp.store.purgeExpiredTxs(expirationHeight, expirationAge)
+	for _, key := range purged {
+		txmp.droppedTxCache.Push(key)
+	}
+	txmp.currentMetrics().EvictedTxs.Add(float64(byHeight + byAge))
+	txmp.currentMetrics().HeightTTLExpirations.Add(float64(byHeight))
 
 	// purge old evicted and seen transactions
-	if txmp.config.TTLDuration == 0 {
+	if ttlDuration == 0 {
 		// ensure that evictedTxs and seenByPeersSet are eventually pruned
 		expirationAge = now.Add(-time.Hour)
 	}
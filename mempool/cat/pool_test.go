@@ -7,12 +7,19 @@ import (
 	"fmt"
 	"math/rand"
 	"os"
+	"reflect"
 	"sort"
 	"strconv"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/go-kit/kit/metrics"
+	"github.com/go-kit/kit/metrics/generic"
+	"github.com/go-kit/kit/metrics/prometheus"
+	stdprometheus "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/require"
 
 	"github.com/tendermint/tendermint/abci/example/code"
@@ -84,8 +91,12 @@ func (app *application) CheckTx(req abci.RequestCheckTx) abci.ResponseCheckTx {
 
 func setup(t testing.TB, cacheSize int, options ...TxPoolOption) *TxPool {
 	t.Helper()
+	return setupWithApp(t, &application{kvstore.NewApplication()}, cacheSize, options...)
+}
+
+func setupWithApp(t testing.TB, app abci.Application, cacheSize int, options ...TxPoolOption) *TxPool {
+	t.Helper()
 
-	app := &application{kvstore.NewApplication()}
 	cc := proxy.NewLocalClientCreator(app)
 
 	cfg := config.TestMempoolConfig()
@@ -514,7 +525,7 @@ func TestTxPool_ConcurrentTxs(t *testing.T) {
 
 func TestTxPool_ExpiredTxs_Timestamp(t *testing.T) {
 	txmp := setup(t, 5000)
-	txmp.config.TTLDuration = 5 * time.Millisecond
+	txmp.SetTTL(5*time.Millisecond, 0)
 
 	added1 := checkTxs(t, txmp, 10, 0)
 	require.Equal(t, len(added1), txmp.Size())
@@ -563,7 +574,7 @@ func TestTxPool_ExpiredTxs_Timestamp(t *testing.T) {
 func TestTxPool_ExpiredTxs_NumBlocks(t *testing.T) {
 	txmp := setup(t, 500)
 	txmp.height = 100
-	txmp.config.TTLNumBlocks = 10
+	txmp.SetTTL(0, 10)
 
 	tTxs := checkTxs(t, txmp, 100, 0)
 	require.Equal(t, len(tTxs), txmp.Size())
@@ -742,3 +753,932 @@ func TestTxPool_BroadcastQueue(t *testing.T) {
 
 	wg.Wait()
 }
+
+func TestTxPool_FirstSeenByPeer(t *testing.T) {
+	firstSeen := stdprometheus.NewCounterVec(stdprometheus.CounterOpts{Name: "first_seen_by_peer"}, []string{"peer_id"})
+	metrics := mempool.NopMetrics()
+	metrics.FirstSeenByPeer = prometheus.NewCounter(firstSeen)
+	txmp := setup(t, 100, WithMetrics(metrics))
+
+	peerA := mempool.TxInfo{SenderID: 1, SenderP2PID: "peer-a"}
+	peerB := mempool.TxInfo{SenderID: 2, SenderP2PID: "peer-b"}
+	txA := types.Tx("sender-a=0000=1")
+	txB := types.Tx("sender-b=0000=1")
+	txLocal := types.Tx("sender-c=0000=1")
+
+	_, err := txmp.TryAddNewTx(txA, txA.Key(), peerA)
+	require.NoError(t, err)
+	// peer B delivers the same tx later so it isn't credited for it
+	_, err = txmp.TryAddNewTx(txA, txA.Key(), peerB)
+	require.ErrorIs(t, err, ErrTxInMempool)
+	_, err = txmp.TryAddNewTx(txB, txB.Key(), peerB)
+	require.NoError(t, err)
+	// txs submitted locally are not attributed to any peer
+	require.NoError(t, txmp.CheckTx(txLocal, nil, mempool.TxInfo{}))
+
+	blockTxs := types.Txs{txA, txB, txLocal}
+	require.NoError(t, txmp.Update(2, blockTxs, abciResponses(len(blockTxs), abci.CodeTypeOK), nil, nil))
+
+	require.Equal(t, float64(1), testutil.ToFloat64(firstSeen.WithLabelValues("peer-a")))
+	require.Equal(t, float64(1), testutil.ToFloat64(firstSeen.WithLabelValues("peer-b")))
+	require.Equal(t, 2, testutil.CollectAndCount(firstSeen))
+}
+
+func TestTxPool_UnsupportedTxVersion(t *testing.T) {
+	const unknownVersion = 0xff
+	unsupported := generic.NewCounter("unsupported_tx_version")
+	metrics := mempool.NopMetrics()
+	metrics.UnsupportedTxVersion = unsupported
+	preCheckFn := func(tx types.Tx) error {
+		if len(tx) > 0 && tx[0] == unknownVersion {
+			return fmt.Errorf("%w: %d", mempool.ErrUnsupportedTxVersion, tx[0])
+		}
+		return nil
+	}
+	txmp := setup(t, 0, WithMetrics(metrics), WithPreCheck(preCheckFn))
+
+	err := txmp.CheckTx(append([]byte{unknownVersion}, "sender=0000=1"...), nil, mempool.TxInfo{})
+	require.True(t, mempool.IsPreCheckError(err))
+	require.Equal(t, float64(1), unsupported.Value())
+
+	// txs rejected by the pre-check for other reasons are not counted
+	failingPreCheck := func(types.Tx) error { return errors.New("too big") }
+	require.NoError(t, txmp.Update(1, nil, nil, failingPreCheck, nil))
+	err = txmp.CheckTx(types.Tx("sender=0001=1"), nil, mempool.TxInfo{})
+	require.True(t, mempool.IsPreCheckError(err))
+	require.Equal(t, float64(1), unsupported.Value())
+}
+
+func TestTxPool_NegativeCacheHits(t *testing.T) {
+	hits := generic.NewCounter("negative_cache_hits")
+	metrics := mempool.NopMetrics()
+	metrics.NegativeCacheHits = hits
+	txmp := setup(t, 100, WithMetrics(metrics))
+	txmp.config.KeepInvalidTxsInCache = true
+
+	// the application rejects txs that aren't of the form sender=key=priority
+	badTx := types.Tx("bad-tx")
+	err := txmp.CheckTx(badTx, nil, mempool.TxInfo{})
+	require.Error(t, err)
+	require.NotErrorIs(t, err, ErrTxAlreadyRejected)
+	require.Zero(t, hits.Value())
+
+	err = txmp.CheckTx(badTx, nil, mempool.TxInfo{})
+	require.ErrorIs(t, err, ErrTxAlreadyRejected)
+	require.Equal(t, float64(1), hits.Value())
+
+	// resubmitting a committed tx is not a hit for a known-bad tx
+	committed := types.Tx("sender=key=1")
+	require.NoError(t, txmp.CheckTx(committed, nil, mempool.TxInfo{}))
+	require.NoError(t, txmp.Update(1, []types.Tx{committed}, abciResponses(1, abci.CodeTypeOK), nil, nil))
+	require.ErrorIs(t, txmp.CheckTx(committed, nil, mempool.TxInfo{}), ErrTxAlreadyRejected)
+	require.Equal(t, float64(1), hits.Value())
+}
+
+func TestTxPool_EmptyUpdates(t *testing.T) {
+	emptyUpdates := generic.NewCounter("empty_updates")
+	metrics := mempool.NopMetrics()
+	metrics.EmptyUpdates = emptyUpdates
+	txmp := setup(t, 100, WithMetrics(metrics))
+
+	residentTx := types.Tx("sender-a=0000=1")
+	require.NoError(t, txmp.CheckTx(residentTx, nil, mempool.TxInfo{}))
+
+	// a block with no txs at all removes nothing from the mempool
+	require.NoError(t, txmp.Update(2, nil, nil, nil, nil))
+	require.Equal(t, float64(1), emptyUpdates.Value())
+
+	// nor does a block made up of txs we never had
+	foreignTxs := types.Txs{types.Tx("sender-b=0000=1"), types.Tx("sender-c=0000=1")}
+	require.NoError(t, txmp.Update(3, foreignTxs, abciResponses(len(foreignTxs), abci.CodeTypeOK), nil, nil))
+	require.Equal(t, float64(2), emptyUpdates.Value())
+	require.Equal(t, 1, txmp.Size())
+
+	// including a single resident tx makes the update non-empty
+	blockTxs := append(foreignTxs, residentTx)
+	require.NoError(t, txmp.Update(4, blockTxs, abciResponses(len(blockTxs), abci.CodeTypeOK), nil, nil))
+	require.Equal(t, float64(2), emptyUpdates.Value())
+	require.Zero(t, txmp.Size())
+}
+
+func TestTxPool_DuplicateOfReapedTx(t *testing.T) {
+	duplicates := generic.NewCounter("duplicate_of_reaped_tx")
+	metrics := mempool.NopMetrics()
+	metrics.DuplicateOfReapedTx = duplicates
+	txmp := setup(t, 100, WithMetrics(metrics))
+
+	reapedTx := types.Tx("sender-a=0000=1")
+	otherTx := types.Tx("sender-b=0001=1")
+	require.NoError(t, txmp.CheckTx(reapedTx, nil, mempool.TxInfo{}))
+
+	require.Len(t, txmp.ReapMaxBytesMaxGas(-1, -1), 1)
+	require.NoError(t, txmp.CheckTx(otherTx, nil, mempool.TxInfo{}))
+
+	// receiving a tx that isn't part of the proposal is not counted
+	_, err := txmp.TryAddNewTx(otherTx, otherTx.Key(), mempool.TxInfo{SenderID: 1})
+	require.ErrorIs(t, err, ErrTxInMempool)
+	require.Zero(t, duplicates.Value())
+
+	_, err = txmp.TryAddNewTx(reapedTx, reapedTx.Key(), mempool.TxInfo{SenderID: 1})
+	require.ErrorIs(t, err, ErrTxInMempool)
+	require.Equal(t, float64(1), duplicates.Value())
+
+	// once the block is committed the proposal is finished
+	require.NoError(t, txmp.Update(2, nil, nil, nil, nil))
+	_, err = txmp.TryAddNewTx(reapedTx, reapedTx.Key(), mempool.TxInfo{SenderID: 1})
+	require.ErrorIs(t, err, ErrTxInMempool)
+	require.Equal(t, float64(1), duplicates.Value())
+}
+
+// slowRecheckApp is an application that takes a while to recheck each tx.
+type slowRecheckApp struct {
+	*application
+	delay time.Duration
+}
+
+func (app *slowRecheckApp) CheckTx(req abci.RequestCheckTx) abci.ResponseCheckTx {
+	if req.Type == abci.CheckTxType_Recheck {
+		time.Sleep(app.delay)
+	}
+	return app.application.CheckTx(req)
+}
+
+func TestTxPool_RecheckBudgetExceeded(t *testing.T) {
+	exceeded := generic.NewCounter("recheck_budget_exceeded")
+	metrics := mempool.NopMetrics()
+	metrics.RecheckBudgetExceeded = exceeded
+
+	app := &slowRecheckApp{&application{kvstore.NewApplication()}, 10 * time.Millisecond}
+	txmp := setupWithApp(t, app, 100, WithMetrics(metrics))
+	txmp.config.RecheckBudget = time.Millisecond
+
+	mustCheckTx(t, txmp, "sender-a=0000=1")
+	require.NoError(t, txmp.Update(2, nil, nil, nil, nil))
+	require.Eventually(t, func() bool {
+		return exceeded.Value() == 1
+	}, time.Second, 10*time.Millisecond)
+
+	// a pass within the budget is not counted
+	app.delay = 0
+	txmp.config.RecheckBudget = time.Minute
+	require.NoError(t, txmp.Update(3, nil, nil, nil, nil))
+	time.Sleep(50 * time.Millisecond)
+	require.Equal(t, float64(1), exceeded.Value())
+}
+
+func TestTxPool_UnknownSenderTxs(t *testing.T) {
+	unknownSender := generic.NewCounter("unknown_sender_txs")
+	metrics := mempool.NopMetrics()
+	metrics.UnknownSenderTxs = unknownSender
+	txmp := setup(t, 100, WithMetrics(metrics))
+
+	mustCheckTx(t, txmp, "sender-a=0000=1")
+	require.Zero(t, unknownSender.Value())
+
+	// the application reports the first part of the tx as its sender
+	mustCheckTx(t, txmp, "=0001=1")
+	require.Equal(t, 2, txmp.Size())
+	require.Equal(t, float64(1), unknownSender.Value())
+}
+
+func TestTxPool_ProposalPinnedTxs(t *testing.T) {
+	pinned := generic.NewGauge("proposal_pinned_txs")
+	metrics := mempool.NopMetrics()
+	metrics.ProposalPinnedTxs = pinned
+	txmp := setup(t, 100, WithMetrics(metrics))
+
+	txs := checkTxs(t, txmp, 10, 0)
+	require.Len(t, txmp.ReapMaxBytesMaxGas(-1, 5), 5)
+	require.Equal(t, float64(5), pinned.Value())
+
+	// a new proposal replaces the previous one
+	require.Len(t, txmp.ReapMaxBytesMaxGas(-1, -1), len(txs))
+	require.Equal(t, float64(len(txs)), pinned.Value())
+
+	require.NoError(t, txmp.Update(2, nil, nil, nil, nil))
+	require.Zero(t, pinned.Value())
+}
+
+func TestTxPool_MaxMsgsPerTxRejects(t *testing.T) {
+	rejects := generic.NewCounter("max_msgs_per_tx_rejects")
+	metrics := mempool.NopMetrics()
+	metrics.MaxMsgsPerTxRejects = rejects
+	// the application rejects txs that aren't of the form sender=key=priority
+	// with code 101 and txs with an invalid priority with code 100
+	txmp := setup(t, 100, WithMetrics(metrics), WithMaxMsgsPerTxCode(101))
+
+	require.Error(t, txmp.CheckTx(types.Tx("sender=key=priority"), nil, mempool.TxInfo{}))
+	require.Zero(t, rejects.Value())
+
+	require.Error(t, txmp.CheckTx(types.Tx("sender=key=1=extra"), nil, mempool.TxInfo{}))
+	require.Equal(t, float64(1), rejects.Value())
+}
+
+func TestTxPool_DuplicateAppCodes(t *testing.T) {
+	require.Panics(t, func() { setup(t, 100, WithMaxMsgsPerTxCode(101), WithInnerDecodeCode(101)) })
+	require.NotPanics(t, func() { setup(t, 100, WithMaxMsgsPerTxCode(101), WithInnerDecodeCode(100)) })
+}
+
+// recordingHistogram is a metrics.Histogram that keeps every observed value.
+type recordingHistogram struct {
+	mtx    sync.Mutex
+	values []float64
+}
+
+func (h *recordingHistogram) With(labelValues ...string) metrics.Histogram { return h }
+
+func (h *recordingHistogram) Observe(value float64) {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	h.values = append(h.values, value)
+}
+
+func (h *recordingHistogram) Values() []float64 {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	return append([]float64(nil), h.values...)
+}
+
+func TestTxPool_EvictionCascades(t *testing.T) {
+	cascades := generic.NewCounter("eviction_cascades")
+	evictedPerAdmission := &recordingHistogram{}
+	metrics := mempool.NopMetrics()
+	metrics.EvictionCascades = cascades
+	metrics.EvictedPerAdmission = evictedPerAdmission
+	txmp := setup(t, 100, WithMetrics(metrics))
+	txmp.config.MaxTxsBytes = 33
+
+	mustCheckTx(t, txmp, "key1=0000=1")
+	mustCheckTx(t, txmp, "key2=0001=2")
+	mustCheckTx(t, txmp, "key3=0002=3")
+	require.Empty(t, evictedPerAdmission.Values())
+
+	// evicting a single tx is not a cascade
+	mustCheckTx(t, txmp, "key4=0003=4")
+	require.Equal(t, []float64{1}, evictedPerAdmission.Values())
+	require.Zero(t, cascades.Value())
+
+	// making room for a big tx evicts all the others
+	mustCheckTx(t, txmp, "big=0123456789abcdefghijklm=10")
+	require.Equal(t, 1, txmp.Size())
+	require.Equal(t, []float64{1, 3}, evictedPerAdmission.Values())
+	require.Equal(t, float64(1), cascades.Value())
+}
+
+func TestTxPool_ExperimentLabel(t *testing.T) {
+	admitted := stdprometheus.NewCounterVec(stdprometheus.CounterOpts{Name: "admitted_txs"}, []string{"experiment"})
+	metrics := mempool.NopMetrics()
+	metrics.AdmittedTxs = prometheus.NewCounter(admitted)
+	txmp := setup(t, 100, WithMetrics(metrics))
+
+	mustCheckTx(t, txmp, "sender-a=0000=1")
+	txmp.SetExperimentLabel("on")
+	mustCheckTx(t, txmp, "sender-b=0000=1")
+	mustCheckTx(t, txmp, "sender-c=0000=1")
+	txmp.SetExperimentLabel("")
+	mustCheckTx(t, txmp, "sender-d=0000=1")
+
+	require.Equal(t, float64(2), testutil.ToFloat64(admitted.WithLabelValues("")))
+	require.Equal(t, float64(2), testutil.ToFloat64(admitted.WithLabelValues("on")))
+	require.Equal(t, 2, testutil.CollectAndCount(admitted))
+
+	// labels beyond the cap are reported as "other"
+	for i := 1; i < maxExperimentLabels; i++ {
+		txmp.SetExperimentLabel(fmt.Sprintf("run-%d", i))
+	}
+	txmp.SetExperimentLabel("one-too-many")
+	mustCheckTx(t, txmp, "sender-e=0000=1")
+	require.Equal(t, float64(1), testutil.ToFloat64(admitted.WithLabelValues(otherLabel)))
+	txmp.SetExperimentLabel("on")
+	mustCheckTx(t, txmp, "sender-f=0000=1")
+	require.Equal(t, float64(3), testutil.ToFloat64(admitted.WithLabelValues("on")))
+}
+
+// feeTokenApp is an application that only accepts fees paid in utia. The fee
+// token of a tx is the part after the last ';'.
+type feeTokenApp struct {
+	*application
+}
+
+const codeUnsupportedFeeToken = 200
+
+func (app *feeTokenApp) CheckTx(req abci.RequestCheckTx) abci.ResponseCheckTx {
+	parts := bytes.Split(req.Tx, []byte(";"))
+	if token := string(parts[len(parts)-1]); token != "utia" {
+		return abci.ResponseCheckTx{Code: codeUnsupportedFeeToken, Info: token}
+	}
+	return app.application.CheckTx(abci.RequestCheckTx{Tx: bytes.Join(parts[:len(parts)-1], nil), Type: req.Type})
+}
+
+func TestTxPool_UnsupportedFeeToken(t *testing.T) {
+	unsupported := stdprometheus.NewCounterVec(stdprometheus.CounterOpts{Name: "unsupported_fee_token"}, []string{"token"})
+	metrics := mempool.NopMetrics()
+	metrics.UnsupportedFeeToken = prometheus.NewCounter(unsupported)
+	txmp := setupWithApp(t, &feeTokenApp{&application{kvstore.NewApplication()}}, 100,
+		WithMetrics(metrics), WithUnsupportedFeeTokenCode(codeUnsupportedFeeToken))
+
+	mustCheckTx(t, txmp, "sender-a=0000=1;utia")
+	require.Error(t, txmp.CheckTx(types.Tx("sender-b=0000=1;uatom"), nil, mempool.TxInfo{}))
+	require.Error(t, txmp.CheckTx(types.Tx("sender-c=0000=1;uatom"), nil, mempool.TxInfo{}))
+	require.Error(t, txmp.CheckTx(types.Tx("sender-d=0000=1;uosmo"), nil, mempool.TxInfo{}))
+
+	require.Equal(t, float64(2), testutil.ToFloat64(unsupported.WithLabelValues("uatom")))
+	require.Equal(t, float64(1), testutil.ToFloat64(unsupported.WithLabelValues("uosmo")))
+	require.Equal(t, 2, testutil.CollectAndCount(unsupported))
+}
+
+func TestTxPool_FIFOFallbacks(t *testing.T) {
+	fallbacks := generic.NewCounter("fifo_fallbacks")
+	metrics := mempool.NopMetrics()
+	metrics.FIFOFallbacks = fallbacks
+	txmp := setup(t, 100, WithMetrics(metrics))
+
+	// the application assigns no priority to txs with a zero priority
+	mustCheckTx(t, txmp, "sender-a=0000=0")
+	require.Len(t, txmp.ReapMaxBytesMaxGas(-1, -1), 1)
+	require.Zero(t, fallbacks.Value())
+
+	mustCheckTx(t, txmp, "sender-b=0000=0")
+	mustCheckTx(t, txmp, "sender-c=0000=0")
+	require.Len(t, txmp.ReapMaxBytesMaxGas(-1, -1), 3)
+	require.Equal(t, float64(1), fallbacks.Value())
+
+	mustCheckTx(t, txmp, "sender-d=0000=5")
+	require.Len(t, txmp.ReapMaxBytesMaxGas(-1, -1), 4)
+	require.Equal(t, float64(1), fallbacks.Value())
+}
+
+func TestTxPool_PluginFilterRejects(t *testing.T) {
+	rejects := stdprometheus.NewCounterVec(stdprometheus.CounterOpts{Name: "plugin_filter_rejects"}, []string{"filter"})
+	metrics := mempool.NopMetrics()
+	metrics.PluginFilterRejects = prometheus.NewCounter(rejects)
+	denySender := func(tx types.Tx) error {
+		if bytes.HasPrefix(tx, []byte("denied=")) {
+			return errors.New("sender is denied")
+		}
+		return nil
+	}
+	maxSize := func(tx types.Tx) error {
+		if len(tx) > 20 {
+			return errors.New("tx too large")
+		}
+		return nil
+	}
+	txmp := setup(t, 100, WithMetrics(metrics), WithFilter("deny", denySender), WithFilter("size", maxSize))
+
+	mustCheckTx(t, txmp, "sender-a=0000=1")
+	err := txmp.CheckTx(types.Tx("denied=0000=1"), nil, mempool.TxInfo{})
+	require.True(t, mempool.IsPreCheckError(err))
+	err = txmp.CheckTx(types.Tx("sender-b=0123456789=1"), nil, mempool.TxInfo{})
+	require.True(t, mempool.IsPreCheckError(err))
+
+	// filters are kept when the pre-check is replaced on update
+	require.NoError(t, txmp.Update(2, nil, nil, mempool.PreCheckMaxBytes(1000), nil))
+	err = txmp.CheckTx(types.Tx("denied=0001=1"), nil, mempool.TxInfo{})
+	require.True(t, mempool.IsPreCheckError(err))
+
+	require.Equal(t, float64(2), testutil.ToFloat64(rejects.WithLabelValues("deny")))
+	require.Equal(t, float64(1), testutil.ToFloat64(rejects.WithLabelValues("size")))
+	require.Equal(t, 1, txmp.Size())
+}
+
+func TestTxPool_CacheConsistencyViolations(t *testing.T) {
+	violations := generic.NewCounter("cache_consistency_violations")
+	metrics := mempool.NopMetrics()
+	metrics.CacheConsistencyViolations = violations
+	txmp := setup(t, 100, WithMetrics(metrics))
+
+	tx := types.Tx("sender-a=0000=1")
+	mustCheckTx(t, txmp, "sender-a=0000=1")
+	require.NoError(t, txmp.Update(2, types.Txs{tx}, abciResponses(1, abci.CodeTypeOK), nil, nil))
+	require.Zero(t, violations.Value())
+
+	// a concurrent check of the same tx is not a violation
+	require.True(t, txmp.store.reserve(tx.Key()))
+	txmp.checkCommittedTx(tx.Key())
+	require.Zero(t, violations.Value())
+	txmp.store.release(tx.Key())
+
+	// a committed tx that is still resident
+	require.True(t, txmp.store.set(newWrappedTx(tx, tx.Key(), 2, 1, 1, "")))
+	txmp.checkCommittedTx(tx.Key())
+	require.Equal(t, float64(1), violations.Value())
+
+	// a committed tx that is missing from the cache
+	txmp.removeTxByKey(tx.Key())
+	txmp.rejectedTxCache.Remove(tx.Key())
+	txmp.checkCommittedTx(tx.Key())
+	require.Equal(t, float64(2), violations.Value())
+}
+
+func TestTxPool_CheckTxBatch(t *testing.T) {
+	t.Run("all valid", func(t *testing.T) {
+		txmp := setup(t, 100)
+
+		txs := types.Txs{
+			types.Tx("sender-a=0000=1"),
+			types.Tx("sender-b=0000=2"),
+			types.Tx("sender-c=0000=3"),
+		}
+		responses := txmp.CheckTxBatch(txs, mempool.TxInfo{})
+		require.Len(t, responses, len(txs))
+		for i, rsp := range responses {
+			require.Equal(t, abci.CodeTypeOK, rsp.Code)
+			require.Equal(t, int64(i+1), rsp.Priority)
+		}
+		require.Equal(t, len(txs), txmp.Size())
+	})
+
+	t.Run("mixed", func(t *testing.T) {
+		txmp := setup(t, 100)
+		mustCheckTx(t, txmp, "sender-a=0000=1")
+
+		responses := txmp.CheckTxBatch(types.Txs{
+			types.Tx("sender-a=0000=1"), // already in the mempool
+			types.Tx("bad"),             // rejected by the application
+			types.Tx("sender-b=0000=1"),
+		}, mempool.TxInfo{})
+		require.Len(t, responses, 3)
+		require.Equal(t, mempool.Codespace, responses[0].Codespace)
+		require.Equal(t, mempool.CodeTypeRejected, responses[0].Code)
+		require.Equal(t, uint32(101), responses[1].Code)
+		require.Empty(t, responses[1].Codespace)
+		require.Equal(t, abci.CodeTypeOK, responses[2].Code)
+		require.Equal(t, 2, txmp.Size())
+	})
+
+	t.Run("pool full", func(t *testing.T) {
+		txmp := setup(t, 100)
+		txmp.config.MaxTxsBytes = 25
+
+		txs := types.Txs{
+			types.Tx("sender-a=0000=1"),
+			types.Tx("sender-b=0000=1"),
+			types.Tx("sender-c=0000=1"),
+			types.Tx("c=0000=1"),
+		}
+		responses := txmp.CheckTxBatch(txs, mempool.TxInfo{})
+		require.Len(t, responses, len(txs))
+		require.Equal(t, abci.CodeTypeOK, responses[0].Code)
+		for _, rsp := range responses[1:] {
+			require.Equal(t, mempool.CodeTypeRejected, rsp.Code)
+			require.Contains(t, rsp.MempoolError, "mempool is full")
+		}
+		// the last tx would have fit but is not checked once the pool is full
+		require.Equal(t, 1, txmp.Size())
+		require.False(t, txmp.Has(txs[3].Key()))
+	})
+
+	t.Run("duplicates", func(t *testing.T) {
+		duplicates := generic.NewCounter("batch_duplicate_rejects")
+		alreadySeen := generic.NewCounter("already_seen_txs")
+		metrics := mempool.NopMetrics()
+		metrics.BatchDuplicateRejects = duplicates
+		metrics.AlreadySeenTxs = alreadySeen
+		txmp := setup(t, 100, WithMetrics(metrics))
+		mustCheckTx(t, txmp, "sender-a=0000=1")
+
+		responses := txmp.CheckTxBatch(types.Txs{
+			types.Tx("sender-a=0000=1"), // already in the mempool
+			types.Tx("sender-b=0000=1"),
+			types.Tx("sender-b=0000=1"), // already in the batch
+			types.Tx("sender-b=0000=1"),
+		}, mempool.TxInfo{})
+		require.Len(t, responses, 4)
+		require.Equal(t, abci.CodeTypeOK, responses[1].Code)
+		for _, i := range []int{0, 2, 3} {
+			require.Equal(t, mempool.CodeTypeRejected, responses[i].Code)
+			require.Contains(t, responses[i].MempoolError, ErrTxInMempool.Error())
+		}
+		require.Equal(t, 2, txmp.Size())
+		require.Equal(t, float64(2), duplicates.Value())
+		require.Equal(t, float64(1), alreadySeen.Value())
+	})
+}
+
+func TestTxPool_ReapBatchSize(t *testing.T) {
+	reapBatchSize := &recordingHistogram{}
+	metrics := mempool.NopMetrics()
+	metrics.ReapBatchSize = reapBatchSize
+	txmp := setup(t, 100, WithMetrics(metrics))
+
+	require.Empty(t, txmp.ReapMaxBytesMaxGas(-1, -1))
+	checkTxs(t, txmp, 10, 0)
+	require.Len(t, txmp.ReapMaxBytesMaxGas(-1, 4), 4)
+	require.Len(t, txmp.ReapMaxBytesMaxGas(-1, -1), 10)
+	// reaping for anything but a proposal is not observed
+	require.Len(t, txmp.ReapMaxTxs(2), 2)
+
+	require.Equal(t, []float64{0, 4, 10}, reapBatchSize.Values())
+}
+
+// frozenSenderApp is an application that rejects the txs of frozen senders on
+// recheck.
+type frozenSenderApp struct {
+	*application
+
+	mtx    sync.Mutex
+	frozen map[string]bool
+}
+
+const codeFrozenSender = 300
+
+func (app *frozenSenderApp) freeze(sender string) {
+	app.mtx.Lock()
+	defer app.mtx.Unlock()
+	app.frozen[sender] = true
+}
+
+func (app *frozenSenderApp) CheckTx(req abci.RequestCheckTx) abci.ResponseCheckTx {
+	rsp := app.application.CheckTx(req)
+	app.mtx.Lock()
+	defer app.mtx.Unlock()
+	if req.Type == abci.CheckTxType_Recheck && app.frozen[rsp.Sender] {
+		return abci.ResponseCheckTx{Code: codeFrozenSender}
+	}
+	return rsp
+}
+
+func TestTxPool_FrozenSenderRemovals(t *testing.T) {
+	removals := generic.NewCounter("frozen_sender_removals")
+	metrics := mempool.NopMetrics()
+	metrics.FrozenSenderRemovals = removals
+	app := &frozenSenderApp{application: &application{kvstore.NewApplication()}, frozen: make(map[string]bool)}
+	txmp := setupWithApp(t, app, 100, WithMetrics(metrics), WithFrozenSenderCode(codeFrozenSender))
+
+	mustCheckTx(t, txmp, "sender-a=0000=1")
+	mustCheckTx(t, txmp, "sender-a=0001=1")
+	mustCheckTx(t, txmp, "sender-b=0000=1")
+
+	app.freeze("sender-a")
+	require.NoError(t, txmp.Update(2, nil, nil, nil, nil))
+	require.Eventually(t, func() bool {
+		return removals.Value() == 2
+	}, time.Second, 10*time.Millisecond)
+	require.Equal(t, 1, txmp.Size())
+}
+
+// staleStateApp is an application whose txs reference a state by their key
+// and which rejects them on recheck once that state is pruned.
+type staleStateApp struct {
+	*application
+
+	mtx    sync.Mutex
+	pruned map[string]bool
+}
+
+const codeStaleStateRef = 400
+
+func (app *staleStateApp) prune(ref string) {
+	app.mtx.Lock()
+	defer app.mtx.Unlock()
+	app.pruned[ref] = true
+}
+
+func (app *staleStateApp) CheckTx(req abci.RequestCheckTx) abci.ResponseCheckTx {
+	rsp := app.application.CheckTx(req)
+	parts := bytes.Split(req.Tx, []byte("="))
+	app.mtx.Lock()
+	defer app.mtx.Unlock()
+	if req.Type == abci.CheckTxType_Recheck && len(parts) == 3 && app.pruned[string(parts[1])] {
+		return abci.ResponseCheckTx{Code: codeStaleStateRef}
+	}
+	return rsp
+}
+
+func TestTxPool_StaleStateRefEvictions(t *testing.T) {
+	evictions := generic.NewCounter("stale_state_ref_evictions")
+	frozen := generic.NewCounter("frozen_sender_removals")
+	metrics := mempool.NopMetrics()
+	metrics.StaleStateRefEvictions = evictions
+	metrics.FrozenSenderRemovals = frozen
+	app := &staleStateApp{application: &application{kvstore.NewApplication()}, pruned: make(map[string]bool)}
+	txmp := setupWithApp(t, app, 100, WithMetrics(metrics),
+		WithStaleStateRefCode(codeStaleStateRef), WithFrozenSenderCode(codeFrozenSender))
+
+	mustCheckTx(t, txmp, "sender-a=0000=1")
+	mustCheckTx(t, txmp, "sender-b=0000=1")
+	mustCheckTx(t, txmp, "sender-c=0001=1")
+
+	app.prune("0000")
+	require.NoError(t, txmp.Update(2, nil, nil, nil, nil))
+	require.Eventually(t, func() bool {
+		return evictions.Value() == 2
+	}, time.Second, 10*time.Millisecond)
+	require.Equal(t, 1, txmp.Size())
+	require.Zero(t, frozen.Value())
+}
+
+func TestTxPool_InnerDecodeFailures(t *testing.T) {
+	failures := generic.NewCounter("inner_decode_failures")
+	metrics := mempool.NopMetrics()
+	metrics.InnerDecodeFailures = failures
+	// the application rejects txs with a priority that can't be parsed with
+	// code 100, which stands in for an inner decode error here
+	txmp := setup(t, 100, WithMetrics(metrics), WithInnerDecodeCode(100), WithMaxMsgsPerTxCode(101))
+
+	require.Error(t, txmp.CheckTx(types.Tx("sender=key=1=extra"), nil, mempool.TxInfo{}))
+	require.Zero(t, failures.Value())
+
+	require.Error(t, txmp.CheckTx(types.Tx("sender=key=priority"), nil, mempool.TxInfo{}))
+	require.Equal(t, float64(1), failures.Value())
+}
+
+func TestTxPool_TTLGauges(t *testing.T) {
+	ttlSeconds := generic.NewGauge("ttl_seconds")
+	ttlBlocks := generic.NewGauge("ttl_blocks")
+	metrics := mempool.NopMetrics()
+	metrics.TTLSeconds = ttlSeconds
+	metrics.TTLBlocks = ttlBlocks
+	txmp := setup(t, 100, WithMetrics(metrics))
+	require.Zero(t, ttlSeconds.Value())
+	require.Zero(t, ttlBlocks.Value())
+
+	txmp.SetTTL(90*time.Second, 20)
+	require.Equal(t, float64(90), ttlSeconds.Value())
+	require.Equal(t, float64(20), ttlBlocks.Value())
+
+	// the txs expire under the new limits
+	checkTxs(t, txmp, 5, 0)
+	require.NoError(t, txmp.Update(txmp.height+21, nil, nil, nil, nil))
+	require.Zero(t, txmp.Size())
+
+	txmp.SetTTL(0, 0)
+	require.Zero(t, ttlSeconds.Value())
+	require.Zero(t, ttlBlocks.Value())
+}
+
+func TestTxPool_SetMetrics(t *testing.T) {
+	txmp := setup(t, 0)
+	size := generic.NewGauge("size")
+	successful := generic.NewCounter("successful")
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 50; i++ {
+			metrics := mempool.NopMetrics()
+			if i%2 == 0 {
+				metrics.Size = size
+				metrics.SuccessfulTxs = successful
+			}
+			txmp.SetMetrics(metrics)
+		}
+	}()
+	for i := 0; i < 10; i++ {
+		txs := checkTxs(t, txmp, 10, 0)
+		require.NoError(t, txmp.Update(int64(i+1), []types.Tx{txs[0].tx}, abciResponses(1, abci.CodeTypeOK), nil, nil))
+	}
+	wg.Wait()
+
+	metrics := mempool.NopMetrics()
+	metrics.Size = size
+	txmp.SetMetrics(metrics)
+	require.Equal(t, float64(txmp.Size()), size.Value())
+}
+
+func TestTxPool_SetMetricsCarriesOverGauges(t *testing.T) {
+	// gaugeMetrics returns metrics in which every gauge is a generic gauge,
+	// indexed by the name of its field
+	gaugeMetrics := func() (*mempool.Metrics, map[string]*generic.Gauge) {
+		m := mempool.NopMetrics()
+		gauges := make(map[string]*generic.Gauge)
+		v := reflect.ValueOf(m).Elem()
+		gaugeType := reflect.TypeOf((*metrics.Gauge)(nil)).Elem()
+		for i := 0; i < v.NumField(); i++ {
+			if field := v.Type().Field(i); field.Type == gaugeType {
+				g := generic.NewGauge(field.Name)
+				v.Field(i).Set(reflect.ValueOf(g))
+				gauges[field.Name] = g
+			}
+		}
+		return m, gauges
+	}
+
+	oldMetrics, oldGauges := gaugeMetrics()
+	classify := func(types.Tx) string { return "v1" }
+	txmp := setup(t, 100, WithMetrics(oldMetrics), WithTxFormatClassifier(classify))
+	txmp.SetTTL(time.Minute, 10)
+	checkTxs(t, txmp, 5, 0)
+	txmp.ReapMaxBytesMaxGas(-1, -1)
+	txmp.SetReadOnly(true)
+
+	newMetrics, newGauges := gaugeMetrics()
+	txmp.SetMetrics(newMetrics)
+	for name, g := range oldGauges {
+		require.Equal(t, g.Value(), newGauges[name].Value(), name)
+	}
+	require.NotZero(t, newGauges["ProposalPinnedTxs"].Value())
+	require.Equal(t, float64(1), newGauges["ReadOnly"].Value())
+	require.NotZero(t, newGauges["ObservedTxFormats"].Value())
+}
+
+func TestTxPool_RecentlyCommittedHits(t *testing.T) {
+	hits := generic.NewCounter("recently_committed_hits")
+	metrics := mempool.NopMetrics()
+	metrics.RecentlyCommittedHits = hits
+	txmp := setup(t, 100, WithMetrics(metrics))
+	txmp.config.KeepInvalidTxsInCache = true
+
+	// a tx rejected by the application is not a recently committed one
+	rejected := types.Tx("sender=key=bad")
+	require.Error(t, txmp.CheckTx(rejected, nil, mempool.TxInfo{}))
+	require.ErrorIs(t, txmp.CheckTx(rejected, nil, mempool.TxInfo{}), ErrTxAlreadyRejected)
+	require.Zero(t, hits.Value())
+
+	committed := types.Tx("sender=key=1")
+	require.NoError(t, txmp.CheckTx(committed, nil, mempool.TxInfo{}))
+	require.NoError(t, txmp.Update(1, []types.Tx{committed}, abciResponses(1, abci.CodeTypeOK), nil, nil))
+	require.ErrorIs(t, txmp.CheckTx(committed, nil, mempool.TxInfo{}), ErrTxAlreadyRejected)
+	require.Equal(t, float64(1), hits.Value())
+}
+
+func TestTxPool_ReadOnly(t *testing.T) {
+	rejects := generic.NewCounter("read_only_mode_rejects")
+	readOnly := generic.NewGauge("read_only")
+	metrics := mempool.NopMetrics()
+	metrics.ReadOnlyModeRejects = rejects
+	metrics.ReadOnly = readOnly
+	txmp := setup(t, 100, WithMetrics(metrics))
+
+	resident := types.Tx("sender=key=1")
+	require.NoError(t, txmp.CheckTx(resident, nil, mempool.TxInfo{}))
+
+	txmp.SetReadOnly(true)
+	require.Equal(t, float64(1), readOnly.Value())
+	require.ErrorIs(t, txmp.CheckTx(types.Tx("sender=other=1"), nil, mempool.TxInfo{}), ErrReadOnly)
+	require.ErrorIs(t, txmp.CheckTx(types.Tx("sender=another=1"), nil, mempool.TxInfo{}), ErrReadOnly)
+	require.Equal(t, float64(2), rejects.Value())
+	// resident txs are still served
+	require.Equal(t, types.Txs{resident}, txmp.ReapMaxTxs(-1))
+
+	txmp.SetReadOnly(false)
+	require.Zero(t, readOnly.Value())
+	require.NoError(t, txmp.CheckTx(types.Tx("sender=other=1"), nil, mempool.TxInfo{}))
+	require.Equal(t, float64(2), rejects.Value())
+}
+
+func TestTxPool_TxsByFormat(t *testing.T) {
+	byFormat := stdprometheus.NewCounterVec(stdprometheus.CounterOpts{Name: "txs_by_format"}, []string{"format"})
+	observed := generic.NewGauge("observed_tx_formats")
+	metrics := mempool.NopMetrics()
+	metrics.TxsByFormat = prometheus.NewCounter(byFormat)
+	metrics.ObservedTxFormats = observed
+	// txs whose sender starts with "v2-" use the new format
+	classify := func(tx types.Tx) string {
+		if bytes.HasPrefix(tx, []byte("v2-")) {
+			return "v2"
+		}
+		return "v1"
+	}
+	txmp := setup(t, 100, WithMetrics(metrics), WithTxFormatClassifier(classify))
+
+	mustCheckTx(t, txmp, "sender-a=0000=1")
+	require.Equal(t, float64(1), observed.Value())
+	mustCheckTx(t, txmp, "v2-sender-b=0000=1")
+	mustCheckTx(t, txmp, "v2-sender-c=0000=1")
+	// rejected txs are not counted
+	require.Error(t, txmp.CheckTx(types.Tx("v2-sender-d=0000"), nil, mempool.TxInfo{}))
+
+	require.Equal(t, float64(1), testutil.ToFloat64(byFormat.WithLabelValues("v1")))
+	require.Equal(t, float64(2), testutil.ToFloat64(byFormat.WithLabelValues("v2")))
+	require.Equal(t, float64(2), observed.Value())
+}
+
+func TestTxPool_AdmittedNearCapacity(t *testing.T) {
+	nearCapacity := generic.NewCounter("admitted_near_capacity")
+	metrics := mempool.NopMetrics()
+	metrics.AdmittedNearCapacity = nearCapacity
+	txmp := setup(t, 100, WithMetrics(metrics), WithCapacityWatermark(0.5))
+	txmp.config.Size = 6
+
+	// the first three txs fill at most half the pool
+	mustCheckTx(t, txmp, "sender-a=0000=1")
+	mustCheckTx(t, txmp, "sender-b=0000=1")
+	mustCheckTx(t, txmp, "sender-c=0000=1")
+	require.Zero(t, nearCapacity.Value())
+
+	mustCheckTx(t, txmp, "sender-d=0000=1")
+	mustCheckTx(t, txmp, "sender-e=0000=1")
+	require.Equal(t, float64(2), nearCapacity.Value())
+
+	// txs that are still being checked don't count towards the watermark
+	nearCapacity = generic.NewCounter("admitted_near_capacity")
+	metrics.AdmittedNearCapacity = nearCapacity
+	txmp = setup(t, 100, WithMetrics(metrics), WithCapacityWatermark(0.5))
+	txmp.config.Size = 6
+	for i := 0; i < 4; i++ {
+		require.True(t, txmp.store.reserve(types.Tx(fmt.Sprintf("pending-%d", i)).Key()))
+	}
+	mustCheckTx(t, txmp, "sender-a=0000=1")
+	require.Zero(t, nearCapacity.Value())
+
+	// but the size of the tx being added does
+	tx := types.Tx("sender-b=0000=1")
+	txmp.config.MaxTxsBytes = 2 * int64(len(tx))
+	mustCheckTx(t, txmp, string(tx))
+	require.Equal(t, float64(1), nearCapacity.Value())
+
+	// watermarks outside of (0, 1) disable the count
+	for _, fraction := range []float64{-0.5, 1, 1.5} {
+		txmp := setup(t, 100, WithCapacityWatermark(fraction))
+		require.Zero(t, txmp.capacityWatermark, fraction)
+	}
+}
+
+// blockingApp is an application whose CheckTx waits to be released.
+type blockingApp struct {
+	*application
+	calls   int32
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (app *blockingApp) CheckTx(req abci.RequestCheckTx) abci.ResponseCheckTx {
+	atomic.AddInt32(&app.calls, 1)
+	app.entered <- struct{}{}
+	<-app.release
+	return app.application.CheckTx(req)
+}
+
+func TestTxPool_InFlightDuplicateRejects(t *testing.T) {
+	inFlight := generic.NewCounter("in_flight_duplicate_rejects")
+	alreadySeen := generic.NewCounter("already_seen_txs")
+	metrics := mempool.NopMetrics()
+	metrics.InFlightDuplicateRejects = inFlight
+	metrics.AlreadySeenTxs = alreadySeen
+	app := &blockingApp{
+		application: &application{kvstore.NewApplication()},
+		entered:     make(chan struct{}, 1),
+		release:     make(chan struct{}),
+	}
+	txmp := setupWithApp(t, app, 100, WithMetrics(metrics))
+
+	tx := types.Tx("sender-a=0000=1")
+	errCh := make(chan error, 1)
+	go func() { errCh <- txmp.CheckTx(tx, nil, mempool.TxInfo{}) }()
+	<-app.entered
+
+	// the first submission is still being checked by the application
+	require.ErrorIs(t, txmp.CheckTx(tx, nil, mempool.TxInfo{}), ErrTxInMempool)
+	close(app.release)
+	require.NoError(t, <-errCh)
+	require.Equal(t, int32(1), atomic.LoadInt32(&app.calls))
+	require.Equal(t, float64(1), inFlight.Value())
+
+	// once added, a duplicate is no longer in flight
+	require.ErrorIs(t, txmp.CheckTx(tx, nil, mempool.TxInfo{}), ErrTxInMempool)
+	require.Equal(t, float64(1), inFlight.Value())
+	require.Equal(t, float64(1), alreadySeen.Value())
+}
+
+func TestTxPool_HeightTTLExpirations(t *testing.T) {
+	heightExpirations := generic.NewCounter("height_ttl_expirations")
+	evicted := generic.NewCounter("evicted_txs")
+	metrics := mempool.NopMetrics()
+	metrics.HeightTTLExpirations = heightExpirations
+	metrics.EvictedTxs = evicted
+	txmp := setup(t, 100, WithMetrics(metrics))
+	txmp.SetTTL(time.Hour, 5)
+
+	mustCheckTx(t, txmp, "sender-a=0000=1")
+	height := txmp.Height()
+
+	// at the boundary the tx is not expired yet
+	require.NoError(t, txmp.Update(height+5, nil, nil, nil, nil))
+	require.Equal(t, 1, txmp.Size())
+	require.Zero(t, heightExpirations.Value())
+
+	require.NoError(t, txmp.Update(height+6, nil, nil, nil, nil))
+	require.Zero(t, txmp.Size())
+	require.Equal(t, float64(1), heightExpirations.Value())
+	require.Equal(t, float64(1), evicted.Value())
+}
+
+func TestTxPool_FastPathInclusions(t *testing.T) {
+	fastPath := generic.NewCounter("fast_path_inclusions")
+	metrics := mempool.NopMetrics()
+	metrics.FastPathInclusions = fastPath
+	txmp := setup(t, 100, WithMetrics(metrics), WithFastPathThreshold(time.Minute))
+
+	mustCheckTx(t, txmp, "sender-a=0000=1")
+	mustCheckTx(t, txmp, "sender-b=0000=1")
+	// backdate one of the txs as if it had waited for several blocks
+	txmp.store.get(types.Tx("sender-b=0000=1").Key()).timestamp = time.Now().Add(-time.Hour)
+
+	require.Len(t, txmp.ReapMaxBytesMaxGas(-1, -1), 2)
+	require.Equal(t, float64(1), fastPath.Value())
+	// reaping for anything but a proposal is not counted
+	require.Len(t, txmp.ReapMaxTxs(-1), 2)
+	require.Equal(t, float64(1), fastPath.Value())
+	// nor is reaping the same tx again for a new round
+	require.Len(t, txmp.ReapMaxBytesMaxGas(-1, -1), 2)
+	require.Equal(t, float64(1), fastPath.Value())
+
+	// nor at a later height, where only the new tx is counted
+	mustCheckTx(t, txmp, "sender-c=0000=1")
+	require.NoError(t, txmp.Update(2, nil, nil, nil, nil))
+	require.Len(t, txmp.ReapMaxBytesMaxGas(-1, -1), 3)
+	require.Equal(t, float64(2), fastPath.Value())
+}
@@ -21,6 +21,10 @@ const (
 	// and searching for the tx from a new peer
 	defaultGossipDelay = 200 * time.Millisecond
 
+	// defaultSeenTxJitter is the default upper bound of the random delay before
+	// broadcasting a SeenTx message
+	defaultSeenTxJitter = 100 * time.Millisecond
+
 	// Content Addressable Tx Pool gossips state based messages (SeenTx and WantTx) on a separate channel
 	// for cross compatibility
 	MempoolStateChannel = byte(0x31)
@@ -39,6 +43,9 @@ type Reactor struct {
 	mempool  *TxPool
 	ids      *mempoolIDs
 	requests *requestScheduler
+
+	// jitter returns the delay before broadcasting a SeenTx message
+	jitter func() time.Duration
 }
 
 type ReactorOptions struct {
@@ -52,6 +59,15 @@ type ReactorOptions struct {
 	// MaxGossipDelay is the maximum allotted time that the reactor expects a transaction to
 	// arrive before issuing a new request to a different peer
 	MaxGossipDelay time.Duration
+
+	// MaxSeenTxJitter is the upper bound of the random delay before the reactor
+	// broadcasts that it has seen a transaction. The delay is a multiple of a
+	// tenth of it
+	MaxSeenTxJitter time.Duration
+
+	// DisableSeenTxJitter means that the reactor broadcasts that it has seen a
+	// transaction without any delay. MaxSeenTxJitter is then ignored
+	DisableSeenTxJitter bool
 }
 
 func (opts *ReactorOptions) VerifyAndComplete() error {
@@ -63,6 +79,12 @@ func (opts *ReactorOptions) VerifyAndComplete() error {
 		opts.MaxGossipDelay = defaultGossipDelay
 	}
 
+	if opts.DisableSeenTxJitter {
+		opts.MaxSeenTxJitter = 0
+	} else if opts.MaxSeenTxJitter == 0 {
+		opts.MaxSeenTxJitter = defaultSeenTxJitter
+	}
+
 	if opts.MaxTxSize < 0 {
 		return fmt.Errorf("max tx size (%d) cannot be negative", opts.MaxTxSize)
 	}
@@ -71,6 +93,10 @@ func (opts *ReactorOptions) VerifyAndComplete() error {
 		return fmt.Errorf("max gossip delay (%d) cannot be negative", opts.MaxGossipDelay)
 	}
 
+	if opts.MaxSeenTxJitter < 0 {
+		return fmt.Errorf("max seen tx jitter (%d) cannot be negative", opts.MaxSeenTxJitter)
+	}
+
 	return nil
 }
 
@@ -85,6 +111,12 @@ func NewReactor(mempool *TxPool, opts *ReactorOptions) (*Reactor, error) {
 		mempool:  mempool,
 		ids:      newMempoolIDs(),
 		requests: newRequestScheduler(opts.MaxGossipDelay, defaultGlobalRequestTimeout),
+		jitter: func() time.Duration {
+			if opts.MaxSeenTxJitter == 0 {
+				return 0
+			}
+			return time.Duration(rand.Intn(10)) * (opts.MaxSeenTxJitter / 10)
+		},
 	}
 	memR.BaseReactor = *p2p.NewBaseReactor("Mempool", memR)
 	return memR, nil
@@ -171,7 +203,7 @@ func (memR *Reactor) RemovePeer(peer p2p.Peer, reason interface{}) {
 	// we won't receive any responses from them.
 	outboundRequests := memR.requests.ClearAllRequestsFrom(peerID)
 	for key := range outboundRequests {
-		memR.mempool.metrics.RequestedTxs.Add(1)
+		memR.mempool.currentMetrics().RequestedTxs.Add(1)
 		memR.findNewPeerToRequestTx(key)
 	}
 }
@@ -252,6 +284,10 @@ func (memR *Reactor) ReceiveEnvelope(e p2p.Envelope) {
 			return
 		}
 		peerID := memR.ids.GetIDForPeer(e.Src.ID())
+		// the message tells us nothing if we have the tx and know the peer has it too
+		if memR.mempool.Has(txKey) && memR.mempool.seenByPeersSet.Has(txKey, peerID) {
+			memR.mempool.currentMetrics().RedundantStateMsgs.With("peer_id", memR.mempool.peerLabels.Get(string(e.Src.ID()))).Add(1)
+		}
 		memR.mempool.PeerHasTx(peerID, txKey)
 		// Check if we don't already have the transaction and that it was recently rejected
 		if memR.mempool.Has(txKey) || memR.mempool.IsRejectedTx(txKey) {
@@ -279,6 +315,12 @@ func (memR *Reactor) ReceiveEnvelope(e p2p.Envelope) {
 			return
 		}
 		tx, has := memR.mempool.Get(txKey)
+		// committed and rejected txs are still in the cache, and so are txs we
+		// dropped, for which our own SeenTx may have been stale
+		if !has && !memR.mempool.IsRejectedTx(txKey) && !memR.mempool.droppedTxCache.Has(txKey) {
+			memR.Logger.Debug("received a WantTx for an unknown tx", "txKey", txKey, "peer", e.Src.ID())
+			memR.mempool.currentMetrics().WantTxNotFound.With("peer_id", memR.mempool.peerLabels.Get(string(e.Src.ID()))).Add(1)
+		}
 		if has && !memR.opts.ListenOnly {
 			peerID := memR.ids.GetIDForPeer(e.Src.ID())
 			memR.Logger.Debug("sending a tx in response to a want msg", "peer", peerID)
@@ -320,7 +362,12 @@ func (memR *Reactor) broadcastSeenTx(txKey types.TxKey) {
 
 	// Add jitter to when the node broadcasts it's seen txs to stagger when nodes
 	// in the network broadcast their seenTx messages.
-	time.Sleep(time.Duration(rand.Intn(10)*10) * time.Millisecond)
+	delay := memR.jitter()
+	if delay > 0 {
+		memR.mempool.currentMetrics().JitteredGossipTxs.Add(1)
+	}
+	memR.mempool.currentMetrics().GossipJitterDelay.Observe(delay.Seconds())
+	time.Sleep(delay)
 
 	for id, peer := range memR.ids.GetAll() {
 		if p, ok := peer.Get(types.PeerStateKey).(PeerState); ok {
@@ -338,7 +385,10 @@ func (memR *Reactor) broadcastSeenTx(txKey types.TxKey) {
 			continue
 		}
 
-		peer.Send(MempoolStateChannel, bz)
+		if peer.Send(MempoolStateChannel, bz) {
+			memR.mempool.currentMetrics().AdvertisedHashes.Add(1)
+			memR.mempool.currentMetrics().AdvertisedBytes.Add(float64(len(bz)))
+		}
 	}
 }
 
@@ -379,10 +429,11 @@ func (memR *Reactor) broadcastNewTx(wtx *wrappedTx) {
 
 // requestTx requests a transaction from a peer and tracks it,
 // requesting it from another peer if the first peer does not respond.
-func (memR *Reactor) requestTx(txKey types.TxKey, peer p2p.Peer) {
+// It returns the size of the request, or zero if it wasn't sent.
+func (memR *Reactor) requestTx(txKey types.TxKey, peer p2p.Peer) int {
 	if peer == nil {
 		// we have disconnected from the peer
-		return
+		return 0
 	}
 	memR.Logger.Debug("requesting tx", "txKey", txKey, "peerID", peer.ID())
 	msg := &protomem.Message{
@@ -396,13 +447,15 @@ func (memR *Reactor) requestTx(txKey types.TxKey, peer p2p.Peer) {
 	}
 
 	success := peer.Send(MempoolStateChannel, bz)
-	if success {
-		memR.mempool.metrics.RequestedTxs.Add(1)
-		requested := memR.requests.Add(txKey, memR.ids.GetIDForPeer(peer.ID()), memR.findNewPeerToRequestTx)
-		if !requested {
-			memR.Logger.Error("have already marked a tx as requested", "txKey", txKey, "peerID", peer.ID())
-		}
+	if !success {
+		return 0
+	}
+	memR.mempool.currentMetrics().RequestedTxs.Add(1)
+	requested := memR.requests.Add(txKey, memR.ids.GetIDForPeer(peer.ID()), memR.findNewPeerToRequestTx)
+	if !requested {
+		memR.Logger.Error("have already marked a tx as requested", "txKey", txKey, "peerID", peer.ID())
 	}
+	return len(bz)
 }
 
 // findNewPeerToSendTx finds a new peer that has already seen the transaction to
@@ -436,7 +489,8 @@ func (memR *Reactor) findNewPeerToRequestTx(txKey types.TxKey) {
 		// we disconnected from that peer, retry again until we exhaust the list
 		memR.findNewPeerToRequestTx(txKey)
 	} else {
-		memR.mempool.metrics.RerequestedTxs.Add(1)
-		memR.requestTx(txKey, peer)
+		memR.mempool.currentMetrics().RerequestedTxs.Add(1)
+		sent := memR.requestTx(txKey, peer)
+		memR.mempool.currentMetrics().RerequestBytes.Add(float64(sent))
 	}
 }
@@ -2,18 +2,25 @@ package cat
 
 import (
 	"encoding/hex"
+	"math/rand"
 	"os"
 	"sort"
 	"sync"
 	"testing"
 	"time"
 
+	"github.com/go-kit/kit/metrics/generic"
+	"github.com/go-kit/kit/metrics/prometheus"
 	"github.com/go-kit/log/term"
 	"github.com/gogo/protobuf/proto"
+	stdprometheus "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
 	"github.com/tendermint/tendermint/abci/example/kvstore"
+	abci "github.com/tendermint/tendermint/abci/types"
 	"github.com/tendermint/tendermint/crypto/ed25519"
 	p2pmock "github.com/tendermint/tendermint/p2p/mock"
 
@@ -152,6 +159,210 @@ func TestReactorBroadcastsSeenTxAfterReceivingTx(t *testing.T) {
 	peers[1].AssertExpectations(t)
 }
 
+func TestReactorCountsAdvertisedHashes(t *testing.T) {
+	reactor, pool := setupReactor(t)
+	hashes := generic.NewCounter("advertised_hashes")
+	advertisedBytes := generic.NewCounter("advertised_bytes")
+	pool.metrics.AdvertisedHashes = hashes
+	pool.metrics.AdvertisedBytes = advertisedBytes
+
+	peers := genPeers(3)
+	for _, peer := range peers {
+		reactor.InitPeer(peer)
+	}
+
+	var seenBytes int
+	for _, data := range []string{"first", "second", "third"} {
+		tx := newDefaultTx(data)
+		key := tx.Key()
+		txMsg := &protomem.Message{
+			Sum: &protomem.Message_Txs{Txs: &protomem.Txs{Txs: [][]byte{tx}}},
+		}
+		txMsgBytes, err := txMsg.Marshal()
+		require.NoError(t, err)
+		seenMsg := &protomem.Message{
+			Sum: &protomem.Message_SeenTx{SeenTx: &protomem.SeenTx{TxKey: key[:]}},
+		}
+		seenMsgBytes, err := seenMsg.Marshal()
+		require.NoError(t, err)
+		seenBytes += len(seenMsgBytes)
+
+		// the sender already has the tx, so only the other two peers are told
+		peers[1].On("Send", MempoolStateChannel, seenMsgBytes).Return(true)
+		peers[2].On("Send", MempoolStateChannel, seenMsgBytes).Return(true)
+		reactor.Receive(mempool.MempoolChannel, peers[0], txMsgBytes)
+	}
+
+	for _, peer := range peers {
+		peer.AssertExpectations(t)
+	}
+	require.Equal(t, float64(6), hashes.Value())
+	require.Equal(t, float64(2*seenBytes), advertisedBytes.Value())
+}
+
+func TestReactorSeenTxJitter(t *testing.T) {
+	reactor, pool := setupReactor(t)
+	jittered := generic.NewCounter("jittered_gossip_txs")
+	delays := &recordingHistogram{}
+	pool.metrics.JitteredGossipTxs = jittered
+	pool.metrics.GossipJitterDelay = delays
+
+	const maxJitter = int64(5 * time.Millisecond)
+	rng := rand.New(rand.NewSource(1))
+	reactor.jitter = func() time.Duration { return time.Duration(rng.Int63n(maxJitter)) }
+	expected := rand.New(rand.NewSource(1))
+
+	peers := genPeers(2)
+	reactor.InitPeer(peers[0])
+	reactor.InitPeer(peers[1])
+	peers[1].On("Send", MempoolStateChannel, mock.Anything).Return(true)
+
+	var want []float64
+	for _, data := range []string{"first", "second", "third"} {
+		tx := newDefaultTx(data)
+		txMsg := &protomem.Message{
+			Sum: &protomem.Message_Txs{Txs: &protomem.Txs{Txs: [][]byte{tx}}},
+		}
+		txMsgBytes, err := txMsg.Marshal()
+		require.NoError(t, err)
+		reactor.Receive(mempool.MempoolChannel, peers[0], txMsgBytes)
+		want = append(want, time.Duration(expected.Int63n(maxJitter)).Seconds())
+	}
+
+	require.Equal(t, want, delays.Values())
+	require.Equal(t, float64(3), jittered.Value())
+}
+
+func TestReactorOptionsSeenTxJitter(t *testing.T) {
+	opts := &ReactorOptions{}
+	require.NoError(t, opts.VerifyAndComplete())
+	require.Equal(t, defaultSeenTxJitter, opts.MaxSeenTxJitter)
+
+	pool, cleanup := newMempoolWithApp(proxy.NewLocalClientCreator(&application{kvstore.NewApplication()}))
+	t.Cleanup(cleanup)
+	reactor, err := NewReactor(pool, opts)
+	require.NoError(t, err)
+	// by default the delay is 0 to 90ms in steps of 10ms
+	for i := 0; i < 100; i++ {
+		delay := reactor.jitter()
+		require.GreaterOrEqual(t, delay, time.Duration(0))
+		require.LessOrEqual(t, delay, 90*time.Millisecond)
+		require.Zero(t, delay%(10*time.Millisecond))
+	}
+
+	opts = &ReactorOptions{MaxSeenTxJitter: time.Second, DisableSeenTxJitter: true}
+	require.NoError(t, opts.VerifyAndComplete())
+	require.Zero(t, opts.MaxSeenTxJitter)
+
+	reactor, err = NewReactor(pool, opts)
+	require.NoError(t, err)
+	require.Zero(t, reactor.jitter())
+
+	require.Error(t, (&ReactorOptions{MaxSeenTxJitter: -time.Second}).VerifyAndComplete())
+}
+
+func TestReactorCountsRerequestBytes(t *testing.T) {
+	reactor, pool := setupReactor(t)
+	rerequestBytes := generic.NewCounter("rerequest_bytes")
+	pool.metrics.RerequestBytes = rerequestBytes
+
+	tx := newDefaultTx("hello")
+	key := tx.Key()
+	msgSeen := &protomem.Message{
+		Sum: &protomem.Message_SeenTx{SeenTx: &protomem.SeenTx{TxKey: key[:]}},
+	}
+	msgSeenB, err := msgSeen.Marshal()
+	require.NoError(t, err)
+
+	msgWant := &protomem.Message{
+		Sum: &protomem.Message_WantTx{WantTx: &protomem.WantTx{TxKey: key[:]}},
+	}
+	msgWantB, err := msgWant.Marshal()
+	require.NoError(t, err)
+
+	peers := genPeers(2)
+	peers[0].On("Send", MempoolStateChannel, msgWantB).Return(true)
+	peers[1].On("Send", MempoolStateChannel, msgWantB).Return(true)
+	reactor.InitPeer(peers[0])
+	reactor.InitPeer(peers[1])
+
+	// the tx is only requested from the first peer that has seen it
+	reactor.Receive(MempoolStateChannel, peers[0], msgSeenB)
+	reactor.Receive(MempoolStateChannel, peers[1], msgSeenB)
+	peers[1].AssertNotCalled(t, "Send", MempoolStateChannel, msgWantB)
+	require.Zero(t, rerequestBytes.Value())
+
+	// once that peer disconnects, the tx is requested again from the other
+	reactor.RemovePeer(peers[0], nil)
+	peers[1].AssertExpectations(t)
+	require.Equal(t, float64(len(msgWantB)), rerequestBytes.Value())
+}
+
+func TestReactorCountsWantTxNotFound(t *testing.T) {
+	reactor, pool := setupReactor(t)
+	notFound := stdprometheus.NewCounterVec(stdprometheus.CounterOpts{Name: "want_tx_not_found"}, []string{"peer_id"})
+	pool.metrics.WantTxNotFound = prometheus.NewCounter(notFound)
+
+	wantTx := func(tx types.Tx) []byte {
+		key := tx.Key()
+		msg := &protomem.Message{
+			Sum: &protomem.Message_WantTx{WantTx: &protomem.WantTx{TxKey: key[:]}},
+		}
+		bz, err := msg.Marshal()
+		require.NoError(t, err)
+		return bz
+	}
+
+	peer := genPeer()
+	reactor.InitPeer(peer)
+
+	// the pool had the committed tx, so the request is not for an unknown tx
+	committedTx := newDefaultTx("committed")
+	require.NoError(t, pool.CheckTx(committedTx, nil, mempool.TxInfo{}))
+	require.NoError(t, pool.Update(2, types.Txs{committedTx}, abciResponses(1, abci.CodeTypeOK), nil, nil))
+	reactor.Receive(MempoolStateChannel, peer, wantTx(committedTx))
+	require.Zero(t, testutil.CollectAndCount(notFound))
+
+	// nor is it for a tx that the pool evicted
+	evictedTx := newDefaultTx("evicted")
+	require.NoError(t, pool.CheckTx(evictedTx, nil, mempool.TxInfo{}))
+	pool.evictTx(pool.store.get(evictedTx.Key()))
+	reactor.Receive(MempoolStateChannel, peer, wantTx(evictedTx))
+	require.Zero(t, testutil.CollectAndCount(notFound))
+
+	reactor.Receive(MempoolStateChannel, peer, wantTx(newDefaultTx("unknown")))
+	require.Equal(t, float64(1), testutil.ToFloat64(notFound.WithLabelValues(string(peer.ID()))))
+}
+
+func TestReactorCountsRedundantStateMsgs(t *testing.T) {
+	reactor, pool := setupReactor(t)
+	redundant := stdprometheus.NewCounterVec(stdprometheus.CounterOpts{Name: "redundant_state_msgs"}, []string{"peer_id"})
+	pool.metrics.RedundantStateMsgs = prometheus.NewCounter(redundant)
+
+	seenTx := func(tx types.Tx) []byte {
+		key := tx.Key()
+		msg := &protomem.Message{
+			Sum: &protomem.Message_SeenTx{SeenTx: &protomem.SeenTx{TxKey: key[:]}},
+		}
+		bz, err := msg.Marshal()
+		require.NoError(t, err)
+		return bz
+	}
+
+	peer := genPeer()
+	reactor.InitPeer(peer)
+	tx := newDefaultTx("hello")
+	require.NoError(t, pool.CheckTx(tx, nil, mempool.TxInfo{}))
+
+	// the first message tells us that the peer has the tx
+	reactor.Receive(MempoolStateChannel, peer, seenTx(tx))
+	require.Zero(t, testutil.CollectAndCount(redundant))
+
+	reactor.Receive(MempoolStateChannel, peer, seenTx(tx))
+	reactor.Receive(MempoolStateChannel, peer, seenTx(tx))
+	require.Equal(t, float64(2), testutil.ToFloat64(redundant.WithLabelValues(string(peer.ID()))))
+}
+
 func TestMempoolVectors(t *testing.T) {
 	testCases := []struct {
 		testName string
@@ -73,6 +73,15 @@ func (s *store) reserve(txKey types.TxKey) bool {
 	return true
 }
 
+// reserved returns true if the key has a placeholder, that is the transaction
+// is being checked but hasn't been added yet.
+func (s *store) reserved(txKey types.TxKey) bool {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	tx, ok := s.txs[txKey]
+	return ok && tx.height == -1
+}
+
 // release is called when a pending transaction failed
 // to enter the mempool. The empty element and key is removed.
 func (s *store) release(txKey types.TxKey) {
@@ -90,6 +99,20 @@ func (s *store) size() int {
 	return len(s.txs)
 }
 
+// residentSize returns the number of transactions in the store, leaving out
+// the placeholders of transactions that are still being checked.
+func (s *store) residentSize() int {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	size := 0
+	for _, tx := range s.txs {
+		if tx.height != -1 {
+			size++
+		}
+	}
+	return size
+}
+
 func (s *store) totalBytes() int64 {
 	s.mtx.RLock()
 	defer s.mtx.RUnlock()
@@ -126,7 +149,8 @@ func (s *store) getTxsBelowPriority(priority int64) ([]*wrappedTx, int64) {
 	txs := make([]*wrappedTx, 0, len(s.txs))
 	bytes := int64(0)
 	for _, tx := range s.txs {
-		if tx.priority < priority {
+		// skip the placeholders of transactions that are still being checked
+		if tx.height != -1 && tx.priority < priority {
 			txs = append(txs, tx)
 			bytes += tx.size()
 		}
@@ -135,19 +159,32 @@ func (s *store) getTxsBelowPriority(priority int64) ([]*wrappedTx, int64) {
 }
 
 // purgeExpiredTxs removes all transactions that are older than the given height
-// and time. Returns the amount of transactions that were removed
-func (s *store) purgeExpiredTxs(expirationHeight int64, expirationAge time.Time) int {
+// and time. Returns the keys of the removed transactions and how many of them
+// were removed by height and by time; a transaction that is older than both is
+// counted by height.
+func (s *store) purgeExpiredTxs(
+	expirationHeight int64,
+	expirationAge time.Time,
+) (purged []types.TxKey, byHeight, byAge int) {
 	s.mtx.Lock()
 	defer s.mtx.Unlock()
-	counter := 0
 	for key, tx := range s.txs {
-		if tx.height < expirationHeight || tx.timestamp.Before(expirationAge) {
-			s.bytes -= tx.size()
-			delete(s.txs, key)
-			counter++
+		switch {
+		// skip the placeholders of transactions that are still being checked
+		case tx.height == -1:
+			continue
+		case tx.height < expirationHeight:
+			byHeight++
+		case tx.timestamp.Before(expirationAge):
+			byAge++
+		default:
+			continue
 		}
+		s.bytes -= tx.size()
+		delete(s.txs, key)
+		purged = append(purged, key)
 	}
-	return counter
+	return purged, byHeight, byAge
 }
 
 func (s *store) reset() {
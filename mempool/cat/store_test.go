@@ -55,6 +55,7 @@ func TestStoreReservingTxs(t *testing.T) {
 	// reserve a tx
 	store.reserve(key)
 	require.True(t, store.has(key))
+	require.True(t, store.reserved(key))
 	// should not update the total bytes
 	require.Zero(t, store.totalBytes())
 
@@ -62,6 +63,7 @@ func TestStoreReservingTxs(t *testing.T) {
 	store.set(wtx)
 	require.Equal(t, tx, store.get(key).tx)
 	require.Equal(t, wtx.size(), store.totalBytes())
+	require.False(t, store.reserved(key))
 
 	// releasing should do nothing on a set tx
 	store.release(key)
@@ -152,6 +154,11 @@ func TestStoreGetTxs(t *testing.T) {
 		actualBz += tx.size()
 	}
 	require.Equal(t, actualBz, bz)
+
+	// reserved txs are not yet in the mempool and can't be evicted
+	require.True(t, store.reserve(types.Tx("reserved").Key()))
+	txs, _ = store.getTxsBelowPriority(int64(numTxs / 2))
+	require.Equal(t, numTxs/2, len(txs))
 }
 
 func TestStoreExpiredTxs(t *testing.T) {
@@ -164,8 +171,17 @@ func TestStoreExpiredTxs(t *testing.T) {
 		store.set(wtx)
 	}
 
+	// reserved txs are not yet in the mempool and can't expire
+	reserved := types.Tx("reserved").Key()
+	require.True(t, store.reserve(reserved))
+
 	// half of them should get purged
-	store.purgeExpiredTxs(int64(numTxs/2), time.Time{})
+	purged, byHeight, byAge := store.purgeExpiredTxs(int64(numTxs/2), time.Time{})
+	require.Len(t, purged, numTxs/2)
+	require.Equal(t, numTxs/2, byHeight)
+	require.Zero(t, byAge)
+	require.True(t, store.reserved(reserved))
+	store.release(reserved)
 
 	remainingTxs := store.getAllTxs()
 	require.Equal(t, numTxs/2, len(remainingTxs))
@@ -173,6 +189,8 @@ func TestStoreExpiredTxs(t *testing.T) {
 		require.GreaterOrEqual(t, tx.height, int64(numTxs/2))
 	}
 
-	store.purgeExpiredTxs(int64(0), time.Now().Add(time.Second))
+	_, byHeight, byAge = store.purgeExpiredTxs(int64(0), time.Now().Add(time.Second))
+	require.Zero(t, byHeight)
+	require.Equal(t, numTxs/2, byAge)
 	require.Empty(t, store.getAllTxs())
 }
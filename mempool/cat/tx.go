@@ -1,14 +1,17 @@
 package cat
 
 import (
+	"sync/atomic"
 	"time"
 
+	"github.com/tendermint/tendermint/p2p"
 	"github.com/tendermint/tendermint/types"
 )
 
 // wrappedTx defines a wrapper around a raw transaction with additional metadata
 // that is used for indexing. With the exception of the map of peers who have
-// seen this transaction, this struct should never be modified
+// seen this transaction and the fast path flag, this struct should never be
+// modified
 type wrappedTx struct {
 	// these fields are immutable
 	tx        types.Tx    // the original transaction data
@@ -18,6 +21,10 @@ type wrappedTx struct {
 	gasWanted int64       // app: gas required to execute this transaction
 	priority  int64       // app: priority value for this transaction
 	sender    string      // app: assigned sender label
+	peer      p2p.ID      // the peer that first delivered this transaction, if any
+
+	// set once the transaction has been counted as a fast path inclusion
+	fastPathCounted atomic.Bool
 }
 
 func newWrappedTx(tx types.Tx, key types.TxKey, height, gasWanted, priority int64, sender string) *wrappedTx {
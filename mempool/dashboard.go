@@ -0,0 +1,126 @@
+package mempool
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+const (
+	// dashboardPrefixVariable is the dashboard variable holding the prefix of
+	// the metric names. It defaults to the prefix used with the default
+	// namespace and can be changed in Grafana to match
+	// mempool_metrics_prefix.
+	dashboardPrefixVariable = "prefix"
+	defaultDashboardPrefix  = "cometbft_" + MetricsSubsystem + "_"
+
+	dashboardPanelWidth  = 12
+	dashboardPanelHeight = 8
+)
+
+type grafanaDashboard struct {
+	Title         string           `json:"title"`
+	Tags          []string         `json:"tags"`
+	SchemaVersion int              `json:"schemaVersion"`
+	Templating    grafanaTemplates `json:"templating"`
+	Panels        []grafanaPanel   `json:"panels"`
+}
+
+type grafanaTemplates struct {
+	List []grafanaVariable `json:"list"`
+}
+
+type grafanaVariable struct {
+	Name    string         `json:"name"`
+	Label   string         `json:"label"`
+	Type    string         `json:"type"`
+	Query   string         `json:"query"`
+	Current grafanaCurrent `json:"current"`
+}
+
+type grafanaCurrent struct {
+	Text  string `json:"text"`
+	Value string `json:"value"`
+}
+
+type grafanaPanel struct {
+	ID          int             `json:"id"`
+	Title       string          `json:"title"`
+	Description string          `json:"description"`
+	Type        string          `json:"type"`
+	GridPos     grafanaGridPos  `json:"gridPos"`
+	Targets     []grafanaTarget `json:"targets"`
+}
+
+type grafanaGridPos struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+	W int `json:"w"`
+	H int `json:"h"`
+}
+
+type grafanaTarget struct {
+	Expr         string `json:"expr"`
+	LegendFormat string `json:"legendFormat,omitempty"`
+	RefID        string `json:"refId"`
+}
+
+// GenerateGrafanaDashboard returns a Grafana dashboard in JSON with a panel
+// for every metric in MetricsCatalog. Counters are plotted as rates and
+// histograms as their 95th percentile. The metric names are prefixed with the
+// dashboard variable "prefix", so the dashboard works with any namespace or
+// mempool_metrics_prefix.
+func GenerateGrafanaDashboard() ([]byte, error) {
+	dashboard := grafanaDashboard{
+		Title:         "Mempool",
+		Tags:          []string{MetricsSubsystem},
+		SchemaVersion: 36,
+		Templating: grafanaTemplates{List: []grafanaVariable{{
+			Name:    dashboardPrefixVariable,
+			Label:   "Metric prefix",
+			Type:    "textbox",
+			Query:   defaultDashboardPrefix,
+			Current: grafanaCurrent{Text: defaultDashboardPrefix, Value: defaultDashboardPrefix},
+		}}},
+	}
+	for i, d := range MetricsCatalog() {
+		dashboard.Panels = append(dashboard.Panels, grafanaPanel{
+			ID:          i + 1,
+			Title:       d.Name,
+			Description: d.Help,
+			Type:        "timeseries",
+			GridPos: grafanaGridPos{
+				X: (i % 2) * dashboardPanelWidth,
+				Y: (i / 2) * dashboardPanelHeight,
+				W: dashboardPanelWidth,
+				H: dashboardPanelHeight,
+			},
+			Targets: []grafanaTarget{dashboardTarget(d)},
+		})
+	}
+	return json.MarshalIndent(dashboard, "", "  ")
+}
+
+// dashboardTarget returns the query that plots the given metric.
+func dashboardTarget(d MetricDescriptor) grafanaTarget {
+	name := "${" + dashboardPrefixVariable + "}" + d.Name
+	by := strings.Join(d.Labels, ", ")
+	target := grafanaTarget{RefID: "A"}
+	if len(d.Labels) > 0 {
+		legend := make([]string, len(d.Labels))
+		for i, label := range d.Labels {
+			legend[i] = "{{" + label + "}}"
+		}
+		target.LegendFormat = strings.Join(legend, " ")
+	}
+	switch d.Type {
+	case MetricTypeCounter:
+		target.Expr = fmt.Sprintf("sum by (%s) (rate(%s[5m]))", by, name)
+	case MetricTypeGauge:
+		target.Expr = fmt.Sprintf("sum by (%s) (%s)", by, name)
+	case MetricTypeHistogram:
+		target.Expr = fmt.Sprintf("histogram_quantile(0.95, sum by (%s) (rate(%s_bucket[5m])))",
+			strings.Join(append([]string{"le"}, d.Labels...), ", "), name)
+	}
+	return target
+}
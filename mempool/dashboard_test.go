@@ -0,0 +1,41 @@
+package mempool
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateGrafanaDashboard(t *testing.T) {
+	bz, err := GenerateGrafanaDashboard()
+	require.NoError(t, err)
+	require.True(t, json.Valid(bz))
+
+	var dashboard struct {
+		Panels []struct {
+			Title   string `json:"title"`
+			Targets []struct {
+				Expr string `json:"expr"`
+			} `json:"targets"`
+		} `json:"panels"`
+	}
+	require.NoError(t, json.Unmarshal(bz, &dashboard))
+
+	catalog := MetricsCatalog()
+	require.Len(t, dashboard.Panels, len(catalog))
+	for i, panel := range dashboard.Panels {
+		d := catalog[i]
+		require.Equal(t, d.Name, panel.Title)
+		require.Len(t, panel.Targets, 1, d.Name)
+		expr := panel.Targets[0].Expr
+		require.Contains(t, expr, "${prefix}"+d.Name, d.Name)
+		switch d.Type {
+		case MetricTypeCounter:
+			require.True(t, strings.Contains(expr, "rate("), expr)
+		case MetricTypeHistogram:
+			require.True(t, strings.HasPrefix(expr, "histogram_quantile("), expr)
+		}
+	}
+}
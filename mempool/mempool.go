@@ -21,6 +21,14 @@ const (
 	UnknownPeerID uint16 = 0
 
 	MaxActiveIDs = math.MaxUint16
+
+	// Codespace is the codespace of the CheckTx responses that the mempool,
+	// rather than the application, creates for transactions it refused.
+	Codespace = "mempool"
+
+	// CodeTypeRejected is the code of the CheckTx responses that the mempool
+	// creates for transactions it refused.
+	CodeTypeRejected uint32 = 1
 )
 
 // Mempool defines the mempool interface.
@@ -145,6 +153,10 @@ func PostCheckMaxGas(maxGas int64) PostCheckFunc {
 // ErrTxInCache is returned to the client if we saw tx earlier
 var ErrTxInCache = errors.New("tx already exists in cache")
 
+// ErrUnsupportedTxVersion should be wrapped by a PreCheckFunc that rejects a
+// transaction because of a version or format that the node doesn't support.
+var ErrUnsupportedTxVersion = errors.New("unsupported tx version")
+
 // TxKey is the fixed length array key used as an index.
 type TxKey [sha256.Size]byte
 
@@ -1,6 +1,9 @@
 package mempool
 
 import (
+	"fmt"
+	"reflect"
+
 	"github.com/go-kit/kit/metrics"
 	"github.com/go-kit/kit/metrics/discard"
 	"github.com/go-kit/kit/metrics/prometheus"
@@ -51,94 +54,680 @@ type Metrics struct {
 	// RerequestedTxs defines the number of times that a requested tx
 	// never received a response in time and a new request was made.
 	RerequestedTxs metrics.Counter
+
+	// RecheckPromotedToFront defines the number of times a recheck raised the
+	// priority of a transaction enough for it to become the highest priority
+	// transaction in the mempool.
+	RecheckPromotedToFront metrics.Counter
+
+	// FirstSeenByPeer defines the number of committed transactions, labelled
+	// by the peer that first delivered them to this node.
+	FirstSeenByPeer metrics.Counter
+
+	// UnsupportedTxVersion defines the number of transactions rejected by the
+	// pre-check because of a version or format the node doesn't support.
+	UnsupportedTxVersion metrics.Counter
+
+	// NegativeCacheHits defines the number of transactions that were rejected
+	// without calling CheckTx because they were found in the cache of rejected
+	// transactions. Recently committed transactions are counted by
+	// RecentlyCommittedHits instead.
+	NegativeCacheHits metrics.Counter
+
+	// EmptyUpdates defines the number of times Update was called with a block
+	// that contained none of the transactions in the mempool.
+	EmptyUpdates metrics.Counter
+
+	// DuplicateOfReapedTx defines the number of transactions received that
+	// duplicate one already reaped for the current proposal.
+	DuplicateOfReapedTx metrics.Counter
+
+	// RecheckBudgetExceeded defines the number of recheck passes that took
+	// longer than the configured recheck budget.
+	RecheckBudgetExceeded metrics.Counter
+
+	// UnknownSenderTxs defines the number of transactions admitted to the
+	// mempool for which the application did not report a sender.
+	UnknownSenderTxs metrics.Counter
+
+	// ProposalPinnedTxs defines the number of transactions reaped for the
+	// current proposal. It returns to zero once the block is committed.
+	ProposalPinnedTxs metrics.Gauge
+
+	// MaxMsgsPerTxRejects defines the number of transactions rejected by the
+	// application for containing too many messages.
+	MaxMsgsPerTxRejects metrics.Counter
+
+	// EvictionCascades defines the number of transactions whose admission
+	// evicted more than one transaction from the mempool.
+	EvictionCascades metrics.Counter
+
+	// Histogram of the number of transactions evicted to make room for a
+	// single admitted transaction.
+	EvictedPerAdmission metrics.Histogram
+
+	// RerequestBytes defines the number of bytes sent in requests for
+	// transactions that were requested before.
+	RerequestBytes metrics.Counter
+
+	// AdmittedTxs defines the number of transactions admitted to the mempool,
+	// labelled by the experiment that was running at the time (see
+	// SetExperimentLabel). The label is empty when no experiment is running.
+	AdmittedTxs metrics.Counter
+
+	// UnsupportedFeeToken defines the number of transactions rejected by the
+	// application for paying fees in an unsupported token, labelled by token.
+	UnsupportedFeeToken metrics.Counter
+
+	// FIFOFallbacks defines the number of times transactions were reaped in
+	// order of arrival because none of them had a priority.
+	FIFOFallbacks metrics.Counter
+
+	// PluginFilterRejects defines the number of transactions rejected by a
+	// filter installed by the operator, labelled by the name of the filter.
+	PluginFilterRejects metrics.Counter
+
+	// CacheConsistencyViolations defines the number of committed transactions
+	// that were still in the mempool, or missing from the cache, after being
+	// removed on Update. It should always be zero.
+	CacheConsistencyViolations metrics.Counter
+
+	// BroadcastBatches defines the number of batches of transactions submitted
+	// with broadcast_tx_batch.
+	BroadcastBatches metrics.Counter
+
+	// Histogram of the number of transactions in a batch submitted with
+	// broadcast_tx_batch.
+	TxsPerBroadcastBatch metrics.Histogram
+
+	// WantTxNotFound defines the number of requests for transactions this node
+	// has no record of, labelled by the peer that sent them. Transactions that
+	// were recently rejected, committed, evicted or expired are not counted.
+	WantTxNotFound metrics.Counter
+
+	// Histogram of the number of transactions reaped for a proposal.
+	ReapBatchSize metrics.Histogram
+
+	// FrozenSenderRemovals defines the number of transactions removed on
+	// recheck because the application froze their sender.
+	FrozenSenderRemovals metrics.Counter
+
+	// InnerDecodeFailures defines the number of transactions rejected by the
+	// application because their inner messages could not be decoded.
+	InnerDecodeFailures metrics.Counter
+
+	// TTLSeconds is the time after which transactions expire from the mempool.
+	// Zero means transactions don't expire by age. The v0 mempool has no TTL
+	// and doesn't report it.
+	TTLSeconds metrics.Gauge
+
+	// TTLBlocks is the number of blocks after which transactions expire from the
+	// mempool. Zero means transactions don't expire by height.
+	TTLBlocks metrics.Gauge
+
+	// RecentlyCommittedHits defines the number of transactions that were rejected
+	// because they were committed recently.
+	RecentlyCommittedHits metrics.Counter
+
+	// AdvertisedHashes defines the number of transaction hashes announced to
+	// peers in SeenTx messages.
+	AdvertisedHashes metrics.Counter
+
+	// AdvertisedBytes defines the size of the SeenTx messages sent to peers.
+	AdvertisedBytes metrics.Counter
+
+	// ReadOnlyModeRejects defines the number of transactions rejected because
+	// the mempool was in read-only mode.
+	ReadOnlyModeRejects metrics.Counter
+
+	// ReadOnly is 1 while the mempool is in read-only mode and 0 otherwise.
+	ReadOnly metrics.Gauge
+
+	// TxsByFormat defines the number of admitted transactions by the format
+	// reported by the configured classifier.
+	TxsByFormat metrics.Counter
+
+	// ObservedTxFormats is the number of distinct transaction formats admitted
+	// so far, up to the cap on the format label.
+	ObservedTxFormats metrics.Gauge
+
+	// BatchDuplicateRejects defines the number of transactions in a broadcast
+	// batch that were rejected because the same batch already added them.
+	BatchDuplicateRejects metrics.Counter
+
+	// StaleStateRefEvictions defines the number of transactions removed on
+	// recheck because the state they reference was pruned.
+	StaleStateRefEvictions metrics.Counter
+
+	// AdmittedNearCapacity defines the number of transactions admitted while
+	// the mempool was filled beyond its capacity watermark.
+	AdmittedNearCapacity metrics.Counter
+
+	// RedundantStateMsgs defines the number of SeenTx messages from peers that
+	// were already known to have a transaction the mempool holds.
+	RedundantStateMsgs metrics.Counter
+
+	// JitteredGossipTxs defines the number of transactions whose SeenTx
+	// broadcast was delayed by a random jitter.
+	JitteredGossipTxs metrics.Counter
+
+	// GossipJitterDelay is the random delay, in seconds, applied before
+	// broadcasting a SeenTx message.
+	GossipJitterDelay metrics.Histogram
+
+	// InFlightDuplicateRejects defines the number of transactions rejected
+	// because the same transaction was being checked at the same time.
+	InFlightDuplicateRejects metrics.Counter
+
+	// HeightTTLExpirations defines the number of transactions that expired
+	// because they were in the mempool for more than the TTL in blocks. These
+	// are also counted by EvictedTxs.
+	HeightTTLExpirations metrics.Counter
+
+	// FastPathInclusions defines the number of transactions reaped for a
+	// proposal within the fast path threshold of entering the mempool.
+	FastPathInclusions metrics.Counter
+}
+
+// MetricType is the type of a metric as defined by Prometheus.
+type MetricType string
+
+const (
+	MetricTypeCounter   MetricType = "counter"
+	MetricTypeGauge     MetricType = "gauge"
+	MetricTypeHistogram MetricType = "histogram"
+)
+
+// MetricDescriptor describes a single metric exposed by this package.
+type MetricDescriptor struct {
+	// Field is the name of the Metrics field holding the metric.
+	Field string
+	// Name is the name of the metric without the namespace and subsystem.
+	Name string
+	Type MetricType
+	Help string
+	// Labels are the labels specific to this metric. They come after any
+	// labels passed to PrometheusMetrics.
+	Labels []string
+	// Buckets are the upper bounds of the histogram buckets. Only set for
+	// histograms.
+	Buckets []float64
+}
+
+// metricDescriptors is the single source of truth for the metrics exposed by
+// this package. PrometheusMetrics, NopMetrics and MetricsCatalog are all
+// derived from it, so every field of Metrics must have an entry here.
+var metricDescriptors = []MetricDescriptor{
+	{
+		Field: "Size",
+		Name:  "size",
+		Type:  MetricTypeGauge,
+		Help:  "Size of the mempool (number of uncommitted transactions).",
+	},
+	{
+		Field:   "TxSizeBytes",
+		Name:    "tx_size_bytes",
+		Type:    MetricTypeHistogram,
+		Help:    "Transaction sizes in bytes.",
+		Buckets: stdprometheus.ExponentialBuckets(1, 3, 17),
+	},
+	{
+		Field: "FailedTxs",
+		Name:  "failed_txs",
+		Type:  MetricTypeCounter,
+		Help:  "Number of failed transactions.",
+	},
+	{
+		Field: "EvictedTxs",
+		Name:  "evicted_txs",
+		Type:  MetricTypeCounter,
+		Help:  "Number of evicted transactions.",
+	},
+	{
+		Field: "SuccessfulTxs",
+		Name:  "successful_txs",
+		Type:  MetricTypeCounter,
+		Help:  "Number of transactions that successfully made it into a block.",
+	},
+	{
+		Field: "RecheckTimes",
+		Name:  "recheck_times",
+		Type:  MetricTypeCounter,
+		Help:  "Number of times transactions are rechecked in the mempool.",
+	},
+	{
+		Field: "AlreadySeenTxs",
+		Name:  "already_seen_txs",
+		Type:  MetricTypeCounter,
+		Help:  "Number of transactions that entered the mempool but were already present in the mempool.",
+	},
+	{
+		Field: "RequestedTxs",
+		Name:  "requested_txs",
+		Type:  MetricTypeCounter,
+		Help:  "Number of initial requests for a transaction",
+	},
+	{
+		Field: "RerequestedTxs",
+		Name:  "rerequested_txs",
+		Type:  MetricTypeCounter,
+		Help:  "Number of times a transaction was requested again after a previous request timed out",
+	},
+	{
+		Field: "RecheckPromotedToFront",
+		Name:  "recheck_promoted_to_front",
+		Type:  MetricTypeCounter,
+		Help:  "Number of times a recheck made a transaction the highest priority transaction in the mempool.",
+	},
+	{
+		Field:  "FirstSeenByPeer",
+		Name:   "first_seen_by_peer",
+		Type:   MetricTypeCounter,
+		Help:   "Number of committed transactions by the peer that first delivered them.",
+		Labels: []string{"peer_id"},
+	},
+	{
+		Field: "UnsupportedTxVersion",
+		Name:  "unsupported_tx_version",
+		Type:  MetricTypeCounter,
+		Help:  "Number of transactions rejected because of an unsupported version or format.",
+	},
+	{
+		Field: "NegativeCacheHits",
+		Name:  "negative_cache_hits",
+		Type:  MetricTypeCounter,
+		Help:  "Number of transactions rejected by the cache of rejected transactions without calling CheckTx.",
+	},
+	{
+		Field: "EmptyUpdates",
+		Name:  "empty_updates",
+		Type:  MetricTypeCounter,
+		Help:  "Number of mempool updates that removed no transactions from the mempool.",
+	},
+	{
+		Field: "DuplicateOfReapedTx",
+		Name:  "duplicate_of_reaped_tx",
+		Type:  MetricTypeCounter,
+		Help:  "Number of received transactions that were already reaped for the current proposal.",
+	},
+	{
+		Field: "RecheckBudgetExceeded",
+		Name:  "recheck_budget_exceeded",
+		Type:  MetricTypeCounter,
+		Help:  "Number of recheck passes that took longer than the configured budget.",
+	},
+	{
+		Field: "UnknownSenderTxs",
+		Name:  "unknown_sender_txs",
+		Type:  MetricTypeCounter,
+		Help:  "Number of admitted transactions without a sender reported by the application.",
+	},
+	{
+		Field: "ProposalPinnedTxs",
+		Name:  "proposal_pinned_txs",
+		Type:  MetricTypeGauge,
+		Help:  "Number of transactions reaped for the current proposal.",
+	},
+	{
+		Field: "MaxMsgsPerTxRejects",
+		Name:  "max_msgs_per_tx_rejects",
+		Type:  MetricTypeCounter,
+		Help:  "Number of transactions rejected for containing too many messages.",
+	},
+	{
+		Field: "EvictionCascades",
+		Name:  "eviction_cascades",
+		Type:  MetricTypeCounter,
+		Help:  "Number of admissions that evicted more than one transaction.",
+	},
+	{
+		Field:   "EvictedPerAdmission",
+		Name:    "evicted_per_admission",
+		Type:    MetricTypeHistogram,
+		Help:    "Number of transactions evicted to make room for a single admitted transaction.",
+		Buckets: stdprometheus.ExponentialBuckets(1, 2, 10),
+	},
+	{
+		Field: "RerequestBytes",
+		Name:  "rerequest_bytes",
+		Type:  MetricTypeCounter,
+		Help:  "Number of bytes sent re-requesting transactions after a previous request failed.",
+	},
+	{
+		Field:  "AdmittedTxs",
+		Name:   "admitted_txs",
+		Type:   MetricTypeCounter,
+		Help:   "Number of transactions admitted to the mempool by experiment.",
+		Labels: []string{"experiment"},
+	},
+	{
+		Field:  "UnsupportedFeeToken",
+		Name:   "unsupported_fee_token",
+		Type:   MetricTypeCounter,
+		Help:   "Number of transactions rejected for paying fees in an unsupported token.",
+		Labels: []string{"token"},
+	},
+	{
+		Field: "FIFOFallbacks",
+		Name:  "fifo_fallbacks",
+		Type:  MetricTypeCounter,
+		Help:  "Number of reaps ordered by arrival because no transaction had a priority.",
+	},
+	{
+		Field:  "PluginFilterRejects",
+		Name:   "plugin_filter_rejects",
+		Type:   MetricTypeCounter,
+		Help:   "Number of transactions rejected by an operator installed filter.",
+		Labels: []string{"filter"},
+	},
+	{
+		Field: "CacheConsistencyViolations",
+		Name:  "cache_consistency_violations",
+		Type:  MetricTypeCounter,
+		Help:  "Number of committed transactions found in the mempool or missing from the cache after removal.",
+	},
+	{
+		Field: "BroadcastBatches",
+		Name:  "broadcast_batches",
+		Type:  MetricTypeCounter,
+		Help:  "Number of batches of transactions submitted together.",
+	},
+	{
+		Field:   "TxsPerBroadcastBatch",
+		Name:    "txs_per_broadcast_batch",
+		Type:    MetricTypeHistogram,
+		Help:    "Number of transactions in a batch submitted together.",
+		Buckets: stdprometheus.ExponentialBuckets(1, 2, 12),
+	},
+	{
+		Field:  "WantTxNotFound",
+		Name:   "want_tx_not_found",
+		Type:   MetricTypeCounter,
+		Help:   "Number of requests received for transactions this node has no record of.",
+		Labels: []string{"peer_id"},
+	},
+	{
+		Field:   "ReapBatchSize",
+		Name:    "reap_batch_size",
+		Type:    MetricTypeHistogram,
+		Help:    "Number of transactions reaped for a proposal.",
+		Buckets: stdprometheus.ExponentialBuckets(1, 2, 14),
+	},
+	{
+		Field: "FrozenSenderRemovals",
+		Name:  "frozen_sender_removals",
+		Type:  MetricTypeCounter,
+		Help:  "Number of transactions removed on recheck because their sender was frozen.",
+	},
+	{
+		Field: "InnerDecodeFailures",
+		Name:  "inner_decode_failures",
+		Type:  MetricTypeCounter,
+		Help:  "Number of transactions rejected because their inner messages could not be decoded.",
+	},
+	{
+		Field: "TTLSeconds",
+		Name:  "ttl_seconds",
+		Type:  MetricTypeGauge,
+		Help:  "Time after which transactions expire from the mempool, in seconds.",
+	},
+	{
+		Field: "TTLBlocks",
+		Name:  "ttl_blocks",
+		Type:  MetricTypeGauge,
+		Help:  "Number of blocks after which transactions expire from the mempool.",
+	},
+	{
+		Field: "RecentlyCommittedHits",
+		Name:  "recently_committed_hits",
+		Type:  MetricTypeCounter,
+		Help:  "Number of transactions rejected because they were committed recently.",
+	},
+	{
+		Field: "AdvertisedHashes",
+		Name:  "advertised_hashes",
+		Type:  MetricTypeCounter,
+		Help:  "Number of transaction hashes announced to peers in SeenTx messages.",
+	},
+	{
+		Field: "AdvertisedBytes",
+		Name:  "advertised_bytes",
+		Type:  MetricTypeCounter,
+		Help:  "Total size of the SeenTx messages sent to peers.",
+	},
+	{
+		Field: "ReadOnlyModeRejects",
+		Name:  "read_only_mode_rejects",
+		Type:  MetricTypeCounter,
+		Help:  "Number of transactions rejected because the mempool was in read-only mode.",
+	},
+	{
+		Field: "ReadOnly",
+		Name:  "read_only",
+		Type:  MetricTypeGauge,
+		Help:  "Whether the mempool is in read-only mode (1) or not (0).",
+	},
+	{
+		Field:  "TxsByFormat",
+		Name:   "txs_by_format",
+		Type:   MetricTypeCounter,
+		Help:   "Number of admitted transactions by format.",
+		Labels: []string{"format"},
+	},
+	{
+		Field: "ObservedTxFormats",
+		Name:  "observed_tx_formats",
+		Type:  MetricTypeGauge,
+		Help:  "Number of distinct transaction formats admitted so far.",
+	},
+	{
+		Field: "BatchDuplicateRejects",
+		Name:  "batch_duplicate_rejects",
+		Type:  MetricTypeCounter,
+		Help:  "Number of transactions rejected as duplicates of a transaction admitted earlier in the same batch.",
+	},
+	{
+		Field: "StaleStateRefEvictions",
+		Name:  "stale_state_ref_evictions",
+		Type:  MetricTypeCounter,
+		Help:  "Number of transactions removed on recheck because the state they reference was pruned.",
+	},
+	{
+		Field: "AdmittedNearCapacity",
+		Name:  "admitted_near_capacity",
+		Type:  MetricTypeCounter,
+		Help:  "Number of transactions admitted while the mempool was filled beyond its capacity watermark.",
+	},
+	{
+		Field:  "RedundantStateMsgs",
+		Name:   "redundant_state_msgs",
+		Type:   MetricTypeCounter,
+		Help:   "Number of SeenTx messages for held transactions from peers already known to have them.",
+		Labels: []string{"peer_id"},
+	},
+	{
+		Field: "JitteredGossipTxs",
+		Name:  "jittered_gossip_txs",
+		Type:  MetricTypeCounter,
+		Help:  "Number of transactions whose SeenTx broadcast was delayed by a random jitter.",
+	},
+	{
+		Field:   "GossipJitterDelay",
+		Name:    "gossip_jitter_delay",
+		Type:    MetricTypeHistogram,
+		Help:    "Random delay applied before broadcasting a SeenTx message, in seconds.",
+		Buckets: stdprometheus.LinearBuckets(0.01, 0.01, 10),
+	},
+	{
+		Field: "InFlightDuplicateRejects",
+		Name:  "in_flight_duplicate_rejects",
+		Type:  MetricTypeCounter,
+		Help:  "Number of transactions rejected because the same transaction was being checked at the same time.",
+	},
+	{
+		Field: "HeightTTLExpirations",
+		Name:  "height_ttl_expirations",
+		Type:  MetricTypeCounter,
+		Help:  "Number of transactions that expired because of the TTL in blocks.",
+	},
+	{
+		Field: "FastPathInclusions",
+		Name:  "fast_path_inclusions",
+		Type:  MetricTypeCounter,
+		Help:  "Number of transactions reaped for a proposal shortly after entering the mempool.",
+	},
+}
+
+// MetricsCatalog returns a description of every metric exposed by this
+// package, in the order they are declared in Metrics.
+func MetricsCatalog() []MetricDescriptor {
+	catalog := make([]MetricDescriptor, len(metricDescriptors))
+	for i, d := range metricDescriptors {
+		d.Labels = append([]string(nil), d.Labels...)
+		d.Buckets = append([]float64(nil), d.Buckets...)
+		catalog[i] = d
+	}
+	return catalog
 }
 
 // PrometheusMetrics returns Metrics build using Prometheus client library.
 // Optionally, labels can be provided along with their values ("foo",
 // "fooValue").
 func PrometheusMetrics(namespace string, labelsAndValues ...string) *Metrics {
+	return PrometheusMetricsWithOptions(PrometheusOptions{Namespace: namespace}, labelsAndValues...)
+}
+
+// PrometheusOptions customizes the Metrics returned by
+// PrometheusMetricsWithOptions.
+type PrometheusOptions struct {
+	// Namespace of the metrics, as given to PrometheusMetrics.
+	Namespace string
+
+	// Prefix, if set, is prepended to every metric name instead of the
+	// namespace and subsystem joined by underscores. It is used as is and so
+	// must be valid in a Prometheus metric name.
+	Prefix string
+
+	// Disabled are the names of the metrics, as listed in MetricsCatalog, that
+	// are not registered and discard everything recorded to them. Unknown
+	// names are ignored, use UnknownMetricNames to find them.
+	Disabled []string
+
+	// Registerer the metrics are registered with. Defaults to the default
+	// Prometheus registerer.
+	Registerer stdprometheus.Registerer
+}
+
+// PrometheusMetricsWithOptions is like PrometheusMetrics but customized by the
+// given options.
+func PrometheusMetricsWithOptions(opts PrometheusOptions, labelsAndValues ...string) *Metrics {
+	prefix := opts.Prefix
+	if prefix == "" {
+		prefix = metricsPrefix(opts.Namespace)
+	}
+	reg := opts.Registerer
+	if reg == nil {
+		reg = stdprometheus.DefaultRegisterer
+	}
 	labels := []string{}
 	for i := 0; i < len(labelsAndValues); i += 2 {
 		labels = append(labels, labelsAndValues[i])
 	}
-	return &Metrics{
-		Size: prometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
-			Namespace: namespace,
-			Subsystem: MetricsSubsystem,
-			Name:      "size",
-			Help:      "Size of the mempool (number of uncommitted transactions).",
-		}, labels).With(labelsAndValues...),
-
-		TxSizeBytes: prometheus.NewHistogramFrom(stdprometheus.HistogramOpts{
-			Namespace: namespace,
-			Subsystem: MetricsSubsystem,
-			Name:      "tx_size_bytes",
-			Help:      "Transaction sizes in bytes.",
-			Buckets:   stdprometheus.ExponentialBuckets(1, 3, 17),
-		}, labels).With(labelsAndValues...),
-
-		FailedTxs: prometheus.NewCounterFrom(stdprometheus.CounterOpts{
-			Namespace: namespace,
-			Subsystem: MetricsSubsystem,
-			Name:      "failed_txs",
-			Help:      "Number of failed transactions.",
-		}, labels).With(labelsAndValues...),
-
-		EvictedTxs: prometheus.NewCounterFrom(stdprometheus.CounterOpts{
-			Namespace: namespace,
-			Subsystem: MetricsSubsystem,
-			Name:      "evicted_txs",
-			Help:      "Number of evicted transactions.",
-		}, labels).With(labelsAndValues...),
-
-		SuccessfulTxs: prometheus.NewCounterFrom(stdprometheus.CounterOpts{
-			Namespace: namespace,
-			Subsystem: MetricsSubsystem,
-			Name:      "successful_txs",
-			Help:      "Number of transactions that successfully made it into a block.",
-		}, labels).With(labelsAndValues...),
-
-		RecheckTimes: prometheus.NewCounterFrom(stdprometheus.CounterOpts{
-			Namespace: namespace,
-			Subsystem: MetricsSubsystem,
-			Name:      "recheck_times",
-			Help:      "Number of times transactions are rechecked in the mempool.",
-		}, labels).With(labelsAndValues...),
-
-		AlreadySeenTxs: prometheus.NewCounterFrom(stdprometheus.CounterOpts{
-			Namespace: namespace,
-			Subsystem: MetricsSubsystem,
-			Name:      "already_seen_txs",
-			Help:      "Number of transactions that entered the mempool but were already present in the mempool.",
-		}, labels).With(labelsAndValues...),
-
-		RequestedTxs: prometheus.NewCounterFrom(stdprometheus.CounterOpts{
-			Namespace: namespace,
-			Subsystem: MetricsSubsystem,
-			Name:      "requested_txs",
-			Help:      "Number of initial requests for a transaction",
-		}, labels).With(labelsAndValues...),
-
-		RerequestedTxs: prometheus.NewCounterFrom(stdprometheus.CounterOpts{
-			Namespace: namespace,
-			Subsystem: MetricsSubsystem,
-			Name:      "rerequested_txs",
-			Help:      "Number of times a transaction was requested again after a previous request timed out",
-		}, labels).With(labelsAndValues...),
+	skip := make(map[string]struct{}, len(opts.Disabled))
+	for _, name := range opts.Disabled {
+		skip[name] = struct{}{}
 	}
+	m := &Metrics{}
+	for _, d := range metricDescriptors {
+		if _, ok := skip[d.Name]; ok {
+			m.set(d.Field, nopMetric(d.Type))
+			continue
+		}
+		// copy the labels so that metrics don't share a backing array
+		metricLabels := append(append([]string{}, labels...), d.Labels...)
+		var metric interface{}
+		switch d.Type {
+		case MetricTypeCounter:
+			cv := stdprometheus.NewCounterVec(stdprometheus.CounterOpts{
+				Name: prefix + d.Name,
+				Help: d.Help,
+			}, metricLabels)
+			reg.MustRegister(cv)
+			metric = prometheus.NewCounter(cv).With(labelsAndValues...)
+		case MetricTypeGauge:
+			gv := stdprometheus.NewGaugeVec(stdprometheus.GaugeOpts{
+				Name: prefix + d.Name,
+				Help: d.Help,
+			}, metricLabels)
+			reg.MustRegister(gv)
+			metric = prometheus.NewGauge(gv).With(labelsAndValues...)
+		case MetricTypeHistogram:
+			hv := stdprometheus.NewHistogramVec(stdprometheus.HistogramOpts{
+				Name:    prefix + d.Name,
+				Help:    d.Help,
+				Buckets: d.Buckets,
+			}, metricLabels)
+			reg.MustRegister(hv)
+			metric = prometheus.NewHistogram(hv).With(labelsAndValues...)
+		}
+		m.set(d.Field, metric)
+	}
+	return m
+}
+
+// metricsPrefix returns the prefix of the metric names in the given namespace:
+// the namespace and subsystem joined by underscores.
+func metricsPrefix(namespace string) string {
+	prefix := MetricsSubsystem + "_"
+	if namespace != "" {
+		prefix = namespace + "_" + prefix
+	}
+	return prefix
 }
 
 // NopMetrics returns no-op Metrics.
 func NopMetrics() *Metrics {
-	return &Metrics{
-		Size:           discard.NewGauge(),
-		TxSizeBytes:    discard.NewHistogram(),
-		FailedTxs:      discard.NewCounter(),
-		EvictedTxs:     discard.NewCounter(),
-		SuccessfulTxs:  discard.NewCounter(),
-		RecheckTimes:   discard.NewCounter(),
-		AlreadySeenTxs: discard.NewCounter(),
-		RequestedTxs:   discard.NewCounter(),
-		RerequestedTxs: discard.NewCounter(),
+	m := &Metrics{}
+	for _, d := range metricDescriptors {
+		m.set(d.Field, nopMetric(d.Type))
+	}
+	return m
+}
+
+// UnknownMetricNames returns the names that don't belong to any metric in
+// MetricsCatalog, in the order they are given.
+func UnknownMetricNames(names []string) []string {
+	known := make(map[string]struct{}, len(metricDescriptors))
+	for _, d := range metricDescriptors {
+		known[d.Name] = struct{}{}
+	}
+	var unknown []string
+	for _, name := range names {
+		if _, ok := known[name]; !ok {
+			unknown = append(unknown, name)
+		}
+	}
+	return unknown
+}
+
+func nopMetric(t MetricType) interface{} {
+	switch t {
+	case MetricTypeCounter:
+		return discard.NewCounter()
+	case MetricTypeGauge:
+		return discard.NewGauge()
+	default:
+		return discard.NewHistogram()
+	}
+}
+
+// set assigns the metric to the Metrics field with the given name. It panics
+// if the field does not exist or has a different type, which means that
+// metricDescriptors is out of sync with Metrics.
+func (m *Metrics) set(field string, metric interface{}) {
+	f := reflect.ValueOf(m).Elem().FieldByName(field)
+	if !f.IsValid() {
+		panic(fmt.Sprintf("mempool: metric descriptor for unknown field %q", field))
 	}
+	f.Set(reflect.ValueOf(metric))
 }
@@ -0,0 +1,113 @@
+package mempool
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/go-kit/kit/metrics"
+	stdprometheus "github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetricsCatalog(t *testing.T) {
+	types := map[MetricType]reflect.Type{
+		MetricTypeCounter:   reflect.TypeOf((*metrics.Counter)(nil)).Elem(),
+		MetricTypeGauge:     reflect.TypeOf((*metrics.Gauge)(nil)).Elem(),
+		MetricTypeHistogram: reflect.TypeOf((*metrics.Histogram)(nil)).Elem(),
+	}
+
+	catalog := make(map[string]MetricDescriptor)
+	names := make(map[string]struct{})
+	for _, d := range MetricsCatalog() {
+		require.NotContains(t, catalog, d.Field, "duplicate catalog entry for field")
+		require.NotContains(t, names, d.Name, "duplicate metric name")
+		require.NotEmpty(t, d.Help, d.Field)
+		catalog[d.Field] = d
+		names[d.Name] = struct{}{}
+	}
+
+	metricsType := reflect.TypeOf(Metrics{})
+	exported := 0
+	for i := 0; i < metricsType.NumField(); i++ {
+		field := metricsType.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		exported++
+		d, ok := catalog[field.Name]
+		require.True(t, ok, "no catalog entry for field %s", field.Name)
+		require.Equal(t, types[d.Type], field.Type, "type mismatch for field %s", field.Name)
+	}
+	require.Len(t, catalog, exported)
+}
+
+func TestNopMetricsSetsAllFields(t *testing.T) {
+	m := reflect.ValueOf(NopMetrics()).Elem()
+	for i := 0; i < m.NumField(); i++ {
+		if m.Type().Field(i).IsExported() {
+			require.False(t, m.Field(i).IsNil(), m.Type().Field(i).Name)
+		}
+	}
+}
+
+func TestPrometheusMetricsSetsAllFields(t *testing.T) {
+	reg := stdprometheus.NewRegistry()
+	m := reflect.ValueOf(PrometheusMetricsWithOptions(PrometheusOptions{Registerer: reg}, "chain_id", "test")).Elem()
+	for i := 0; i < m.NumField(); i++ {
+		if m.Type().Field(i).IsExported() {
+			require.False(t, m.Field(i).IsNil(), m.Type().Field(i).Name)
+		}
+	}
+}
+
+func TestPrometheusMetricsPrefix(t *testing.T) {
+	reg := stdprometheus.NewRegistry()
+	m := PrometheusMetricsWithOptions(PrometheusOptions{
+		Namespace:  "cometbft",
+		Prefix:     "custom:mempool:",
+		Registerer: reg,
+	}, "chain_id", "test")
+	m.Size.Set(1)
+
+	families, err := reg.Gather()
+	require.NoError(t, err)
+	gathered := make(map[string]struct{})
+	for _, family := range families {
+		gathered[family.GetName()] = struct{}{}
+	}
+	require.Contains(t, gathered, "custom:mempool:size")
+	require.NotContains(t, gathered, "custom:mempool:mempool_size")
+}
+
+func TestPrometheusMetricsDisabled(t *testing.T) {
+	reg := stdprometheus.NewRegistry()
+	m := PrometheusMetricsWithOptions(PrometheusOptions{
+		Prefix:     "except:mempool:",
+		Disabled:   []string{"size", "failed_txs"},
+		Registerer: reg,
+	}, "chain_id", "test")
+	m.Size.Set(1)
+	m.FailedTxs.Add(1)
+	m.EvictedTxs.Add(1)
+
+	families, err := reg.Gather()
+	require.NoError(t, err)
+	gathered := make(map[string]struct{})
+	for _, family := range families {
+		gathered[family.GetName()] = struct{}{}
+	}
+	require.NotContains(t, gathered, "except:mempool:size")
+	require.NotContains(t, gathered, "except:mempool:failed_txs")
+	require.Contains(t, gathered, "except:mempool:evicted_txs")
+}
+
+func TestMetricsPrefix(t *testing.T) {
+	require.Equal(t, "mempool_", metricsPrefix(""))
+	require.Equal(t, "cometbft_mempool_", metricsPrefix("cometbft"))
+}
+
+func TestUnknownMetricNames(t *testing.T) {
+	require.Empty(t, UnknownMetricNames([]string{"size", "failed_txs"}))
+	require.Equal(t, []string{"sizes", "mempool_size"},
+		UnknownMetricNames([]string{"sizes", "size", "mempool_size"}))
+}
@@ -225,6 +225,9 @@ func (mem *CListMempool) CheckTx(
 
 	if mem.preCheck != nil {
 		if err := mem.preCheck(tx); err != nil {
+			if errors.Is(err, mempool.ErrUnsupportedTxVersion) {
+				mem.metrics.UnsupportedTxVersion.Add(1)
+			}
 			return mempool.ErrPreCheck{
 				Reason: err,
 			}
@@ -4,12 +4,14 @@ import (
 	"bytes"
 	"crypto/rand"
 	"encoding/binary"
+	"errors"
 	"fmt"
 	mrand "math/rand"
 	"os"
 	"testing"
 	"time"
 
+	"github.com/go-kit/kit/metrics/generic"
 	"github.com/gogo/protobuf/proto"
 	gogotypes "github.com/gogo/protobuf/types"
 	"github.com/stretchr/testify/assert"
@@ -209,6 +211,34 @@ func TestMempoolFilters(t *testing.T) {
 	}
 }
 
+func TestMempoolUnsupportedTxVersion(t *testing.T) {
+	app := kvstore.NewApplication()
+	cc := proxy.NewLocalClientCreator(app)
+	mp, cleanup := newMempoolWithApp(cc)
+	defer cleanup()
+	unsupported := generic.NewCounter("unsupported_tx_version")
+	mp.metrics.UnsupportedTxVersion = unsupported
+
+	const unknownVersion = 0xff
+	preCheckFn := func(tx types.Tx) error {
+		if len(tx) > 0 && tx[0] == unknownVersion {
+			return fmt.Errorf("%w: %d", mempool.ErrUnsupportedTxVersion, tx[0])
+		}
+		return nil
+	}
+	require.NoError(t, mp.Update(1, nil, nil, preCheckFn, nil))
+	err := mp.CheckTx(append([]byte{unknownVersion}, "a=1"...), nil, mempool.TxInfo{})
+	require.True(t, mempool.IsPreCheckError(err))
+	require.Equal(t, float64(1), unsupported.Value())
+
+	// txs rejected by the pre-check for other reasons are not counted
+	failingPreCheck := func(types.Tx) error { return errors.New("too big") }
+	require.NoError(t, mp.Update(2, nil, nil, failingPreCheck, nil))
+	err = mp.CheckTx(types.Tx("b=1"), nil, mempool.TxInfo{})
+	require.True(t, mempool.IsPreCheckError(err))
+	require.Equal(t, float64(1), unsupported.Value())
+}
+
 func TestMempoolUpdate(t *testing.T) {
 	app := kvstore.NewApplication()
 	cc := proxy.NewLocalClientCreator(app)
@@ -1,6 +1,7 @@
 package v1
 
 import (
+	"errors"
 	"fmt"
 	"runtime"
 	"sort"
@@ -55,6 +56,7 @@ type TxMempool struct {
 	txs        *clist.CList // valid transactions (passed CheckTx)
 	txByKey    map[types.TxKey]*clist.CElement
 	txBySender map[string]*clist.CElement // for sender != ""
+	head       *WrappedTx                 // first tx to be reaped; nil if unknown
 }
 
 // NewTxMempool constructs a new, empty priority mempool at the specified
@@ -87,6 +89,9 @@ func NewTxMempool(
 		opt(txmp)
 	}
 
+	txmp.metrics.TTLSeconds.Set(cfg.TTLDuration.Seconds())
+	txmp.metrics.TTLBlocks.Set(float64(cfg.TTLNumBlocks))
+
 	return txmp
 }
 
@@ -191,6 +196,9 @@ func (txmp *TxMempool) CheckTx(tx types.Tx, cb func(*abci.Response), txInfo memp
 		// If a precheck hook is defined, call it before invoking the application.
 		if txmp.preCheck != nil {
 			if err := txmp.preCheck(tx); err != nil {
+				if errors.Is(err, mempool.ErrUnsupportedTxVersion) {
+					txmp.metrics.UnsupportedTxVersion.Add(1)
+				}
 				txmp.metrics.FailedTxs.Add(1)
 				return 0, mempool.ErrPreCheck{Reason: err}
 			}
@@ -252,13 +260,7 @@ func (txmp *TxMempool) RemoveTxByKey(txKey types.TxKey) error {
 // The caller must hold txmp.mtx excluxively.
 func (txmp *TxMempool) removeTxByKey(key types.TxKey) error {
 	if elt, ok := txmp.txByKey[key]; ok {
-		w := elt.Value.(*WrappedTx)
-		delete(txmp.txByKey, key)
-		delete(txmp.txBySender, w.sender)
-		txmp.txs.Remove(elt)
-		elt.DetachPrev()
-		elt.DetachNext()
-		atomic.AddInt64(&txmp.txsBytes, -w.Size())
+		txmp.removeTxByElement(elt)
 		return nil
 	}
 	return fmt.Errorf("transaction %x not found", key)
@@ -270,6 +272,9 @@ func (txmp *TxMempool) removeTxByElement(elt *clist.CElement) {
 	w := elt.Value.(*WrappedTx)
 	delete(txmp.txByKey, w.tx.Key())
 	delete(txmp.txBySender, w.sender)
+	if txmp.head == w {
+		txmp.head = nil
+	}
 	txmp.txs.Remove(elt)
 	elt.DetachPrev()
 	elt.DetachNext()
@@ -600,6 +605,9 @@ func (txmp *TxMempool) insertTx(wtx *WrappedTx) {
 	if s := wtx.Sender(); s != "" {
 		txmp.txBySender[s] = elt
 	}
+	if txmp.head != nil && sortsBefore(wtx, wtx.priority, txmp.head) {
+		txmp.head = wtx
+	}
 
 	atomic.AddInt64(&txmp.txsBytes, wtx.Size())
 }
@@ -631,6 +639,14 @@ func (txmp *TxMempool) handleRecheckResult(tx types.Tx, checkTxRes *abci.Respons
 	}
 
 	if checkTxRes.Code == abci.CodeTypeOK && err == nil {
+		head := txmp.headTx()
+		switch {
+		case head != wtx && sortsBefore(wtx, checkTxRes.Priority, head):
+			txmp.metrics.RecheckPromotedToFront.Add(1)
+			txmp.head = wtx
+		case head == wtx && checkTxRes.Priority < wtx.Priority():
+			txmp.head = nil
+		}
 		wtx.SetPriority(checkTxRes.Priority)
 		return // N.B. Size of mempool did not change
 	}
@@ -650,6 +666,29 @@ func (txmp *TxMempool) handleRecheckResult(tx types.Tx, checkTxRes *abci.Respons
 	txmp.metrics.Size.Set(float64(txmp.Size()))
 }
 
+// headTx returns the transaction that would be reaped first from the mempool.
+// The result is cached until the head is removed or demoted, so that a recheck
+// pass does not scan the whole mempool for each result.
+//
+// The caller must hold txmp.mtx.
+func (txmp *TxMempool) headTx() *WrappedTx {
+	if txmp.head == nil {
+		for _, elt := range txmp.txByKey {
+			w := elt.Value.(*WrappedTx)
+			if txmp.head == nil || sortsBefore(w, w.priority, txmp.head) {
+				txmp.head = w
+			}
+		}
+	}
+	return txmp.head
+}
+
+// sortsBefore reports whether wtx, if it had the given priority, would be
+// reaped before other.
+func sortsBefore(wtx *WrappedTx, priority int64, other *WrappedTx) bool {
+	return priority > other.priority || (priority == other.priority && wtx.timestamp.Before(other.timestamp))
+}
+
 // recheckTransactions initiates re-CheckTx ABCI calls for all the transactions
 // currently in the mempool. It reports the number of recheck calls that were
 // successfully initiated.
@@ -13,6 +13,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/go-kit/kit/metrics/generic"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -421,6 +422,33 @@ func TestTxMempool_CheckTxExceedsMaxSize(t *testing.T) {
 	require.NoError(t, txmp.CheckTx(tx, nil, mempool.TxInfo{SenderID: 0}))
 }
 
+func TestTxMempool_UnsupportedTxVersion(t *testing.T) {
+	const unknownVersion = 0xff
+	unsupported := generic.NewCounter("unsupported_tx_version")
+	metrics := mempool.NopMetrics()
+	metrics.UnsupportedTxVersion = unsupported
+	preCheckFn := func(tx types.Tx) error {
+		if len(tx) > 0 && tx[0] == unknownVersion {
+			return fmt.Errorf("%w: %d", mempool.ErrUnsupportedTxVersion, tx[0])
+		}
+		return nil
+	}
+	txmp := setup(t, 0, WithMetrics(metrics), WithPreCheck(preCheckFn))
+
+	err := txmp.CheckTx(append([]byte{unknownVersion}, "sender=0000=1"...), nil, mempool.TxInfo{})
+	require.True(t, mempool.IsPreCheckError(err))
+	require.Equal(t, float64(1), unsupported.Value())
+
+	// txs rejected by the pre-check for other reasons are not counted
+	failingPreCheck := func(types.Tx) error { return errors.New("too big") }
+	txmp.Lock()
+	require.NoError(t, txmp.Update(1, nil, nil, failingPreCheck, nil))
+	txmp.Unlock()
+	err = txmp.CheckTx(types.Tx("sender=0001=1"), nil, mempool.TxInfo{})
+	require.True(t, mempool.IsPreCheckError(err))
+	require.Equal(t, float64(1), unsupported.Value())
+}
+
 func TestTxMempool_CheckTxSamePeer(t *testing.T) {
 	txmp := setup(t, 100)
 	peerID := uint16(1)
@@ -570,6 +598,21 @@ func TestTxMempool_ExpiredTxs_Timestamp(t *testing.T) {
 	}
 }
 
+func TestTxMempool_TTLMetrics(t *testing.T) {
+	ttlSeconds := generic.NewGauge("ttl_seconds")
+	ttlBlocks := generic.NewGauge("ttl_blocks")
+	metrics := mempool.NopMetrics()
+	metrics.TTLSeconds = ttlSeconds
+	metrics.TTLBlocks = ttlBlocks
+	setup(t, 0, WithMetrics(metrics), func(txmp *TxMempool) {
+		txmp.config.TTLDuration = 90 * time.Second
+		txmp.config.TTLNumBlocks = 12
+	})
+
+	require.Equal(t, float64(90), ttlSeconds.Value())
+	require.Equal(t, float64(12), ttlBlocks.Value())
+}
+
 func TestTxMempool_ExpiredTxs_NumBlocks(t *testing.T) {
 	txmp := setup(t, 500)
 	txmp.height = 100
@@ -656,6 +699,57 @@ func TestTxMempool_CheckTxPostCheckError(t *testing.T) {
 	}
 }
 
+func TestTxMempool_RecheckPromotedToFront(t *testing.T) {
+	promoted := generic.NewCounter("recheck_promoted_to_front")
+	metrics := mempool.NopMetrics()
+	metrics.RecheckPromotedToFront = promoted
+	txmp := setup(t, 0, WithMetrics(metrics))
+
+	mustCheckTx(t, txmp, "key1=0000=10")
+	mustCheckTx(t, txmp, "key2=0001=5")
+	lowTx := types.Tx("key2=0001=5")
+
+	// Raising the priority without overtaking the head is not a promotion.
+	txmp.handleRecheckResult(lowTx, &abci.ResponseCheckTx{Code: abci.CodeTypeOK, Priority: 8})
+	require.Zero(t, promoted.Value())
+
+	// Overtaking the head is.
+	txmp.handleRecheckResult(lowTx, &abci.ResponseCheckTx{Code: abci.CodeTypeOK, Priority: 20})
+	require.Equal(t, float64(1), promoted.Value())
+	require.Equal(t, lowTx, txmp.ReapMaxTxs(1)[0])
+
+	// The tx is already at the front, so raising it further is not counted.
+	txmp.handleRecheckResult(lowTx, &abci.ResponseCheckTx{Code: abci.CodeTypeOK, Priority: 30})
+	require.Equal(t, float64(1), promoted.Value())
+
+	// Demoting the head lets the tx overtake the new head again.
+	txmp.handleRecheckResult(lowTx, &abci.ResponseCheckTx{Code: abci.CodeTypeOK, Priority: 1})
+	txmp.handleRecheckResult(lowTx, &abci.ResponseCheckTx{Code: abci.CodeTypeOK, Priority: 15})
+	require.Equal(t, float64(2), promoted.Value())
+}
+
+func TestTxMempool_RecheckPromotedAfterHeadCommitted(t *testing.T) {
+	promoted := generic.NewCounter("recheck_promoted_to_front")
+	metrics := mempool.NopMetrics()
+	metrics.RecheckPromotedToFront = promoted
+	txmp := setup(t, 0, WithMetrics(metrics))
+	txmp.config.Recheck = false
+
+	mustCheckTx(t, txmp, "key1=0000=30")
+	mustCheckTx(t, txmp, "key2=0001=20")
+	mustCheckTx(t, txmp, "key3=0002=10")
+	require.Equal(t, types.Tx("key1=0000=30"), txmp.headTx().tx)
+
+	// commit the head, leaving key2 in front of key3
+	txmp.Lock()
+	require.NoError(t, txmp.Update(1, []types.Tx{types.Tx("key1=0000=30")},
+		abciResponses(1, abci.CodeTypeOK), nil, nil))
+	txmp.Unlock()
+
+	txmp.handleRecheckResult(types.Tx("key3=0002=10"), &abci.ResponseCheckTx{Code: abci.CodeTypeOK, Priority: 25})
+	require.Equal(t, float64(1), promoted.Value())
+}
+
 func TestRemoveBlobTx(t *testing.T) {
 	txmp := setup(t, 500)
 	namespaceOne := bytes.Repeat([]byte{1}, consts.NamespaceIDSize)
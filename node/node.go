@@ -124,9 +124,14 @@ type MetricsProvider func(chainID string) (*cs.Metrics, *p2p.Metrics, *mempl.Met
 func DefaultMetricsProvider(config *cfg.InstrumentationConfig) MetricsProvider {
 	return func(chainID string) (*cs.Metrics, *p2p.Metrics, *mempl.Metrics, *sm.Metrics) {
 		if config.Prometheus {
+			mempoolMetrics := mempl.PrometheusMetricsWithOptions(mempl.PrometheusOptions{
+				Namespace: config.Namespace,
+				Prefix:    config.MempoolMetricsPrefix,
+				Disabled:  config.MempoolDisabledMetrics,
+			}, "chain_id", chainID)
 			return cs.PrometheusMetrics(config.Namespace, "chain_id", chainID),
 				p2p.PrometheusMetrics(config.Namespace, "chain_id", chainID),
-				mempl.PrometheusMetrics(config.Namespace, "chain_id", chainID),
+				mempoolMetrics,
 				sm.PrometheusMetrics(config.Namespace, "chain_id", chainID)
 		}
 		return cs.NopMetrics(), p2p.NopMetrics(), mempl.NopMetrics(), sm.NopMetrics()
@@ -218,6 +223,7 @@ type Node struct {
 	bcReactor         p2p.Reactor       // for fast-syncing
 	mempoolReactor    p2p.Reactor       // for gossipping transactions
 	mempool           mempl.Mempool
+	memplMetrics      *mempl.Metrics
 	stateSync         bool                    // whether the node should state sync on startup
 	stateSyncReactor  *statesync.Reactor      // for hosting and restoring state sync snapshots
 	stateSyncProvider statesync.StateProvider // provides state data for bootstrapping a node
@@ -392,8 +398,10 @@ func createMempoolAndMempoolReactor(
 		reactor, err := mempoolv2.NewReactor(
 			mp,
 			&mempoolv2.ReactorOptions{
-				ListenOnly: !config.Mempool.Broadcast,
-				MaxTxSize:  config.Mempool.MaxTxBytes,
+				ListenOnly:          !config.Mempool.Broadcast,
+				MaxTxSize:           config.Mempool.MaxTxBytes,
+				MaxSeenTxJitter:     config.Mempool.MaxSeenTxJitter,
+				DisableSeenTxJitter: config.Mempool.DisableSeenTxJitter,
 			},
 		)
 		if err != nil {
@@ -834,6 +842,9 @@ func NewNode(config *cfg.Config,
 
 	logNodeStartupInfo(state, pubKey, logger, consensusLogger)
 
+	if unknown := mempl.UnknownMetricNames(config.Instrumentation.MempoolDisabledMetrics); len(unknown) > 0 {
+		logger.Error("ignoring unknown mempool metrics in mempool_disabled_metrics", "names", unknown)
+	}
 	csMetrics, p2pMetrics, memplMetrics, smMetrics := metricsProvider(genDoc.ChainID)
 
 	// create an optional influxdb client to send arbitary data to a remote
@@ -969,6 +980,7 @@ func NewNode(config *cfg.Config,
 		bcReactor:        bcReactor,
 		mempoolReactor:   mempoolReactor,
 		mempool:          mempool,
+		memplMetrics:     memplMetrics,
 		consensusState:   consensusState,
 		consensusReactor: consensusReactor,
 		stateSyncReactor: stateSyncReactor,
@@ -1145,6 +1157,7 @@ func (n *Node) ConfigureRPC() error {
 		ConsensusReactor: n.consensusReactor,
 		EventBus:         n.eventBus,
 		Mempool:          n.mempool,
+		MempoolMetrics:   n.memplMetrics,
 
 		Logger: n.Logger.With("module", "rpc"),
 
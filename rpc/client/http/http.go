@@ -271,6 +271,18 @@ func (c *baseRPCClient) BroadcastTxSync(
 	return c.broadcastTX(ctx, "broadcast_tx_sync", tx)
 }
 
+func (c *baseRPCClient) BroadcastTxBatch(
+	ctx context.Context,
+	txs []types.Tx,
+) (*ctypes.ResultBroadcastTxBatch, error) {
+	result := new(ctypes.ResultBroadcastTxBatch)
+	_, err := c.caller.Call(ctx, "broadcast_tx_batch", map[string]interface{}{"txs": txs}, result)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
 func (c *baseRPCClient) broadcastTX(
 	ctx context.Context,
 	route string,
@@ -147,6 +147,7 @@ type MempoolClient interface {
 	UnconfirmedTxs(ctx context.Context, limit *int) (*ctypes.ResultUnconfirmedTxs, error)
 	NumUnconfirmedTxs(context.Context) (*ctypes.ResultUnconfirmedTxs, error)
 	CheckTx(context.Context, types.Tx) (*ctypes.ResultCheckTx, error)
+	BroadcastTxBatch(context.Context, []types.Tx) (*ctypes.ResultBroadcastTxBatch, error)
 }
 
 // EvidenceClient is used for submitting an evidence of the malicious
@@ -99,6 +99,10 @@ func (c *Local) BroadcastTxSync(ctx context.Context, tx types.Tx) (*ctypes.Resul
 	return core.BroadcastTxSync(c.ctx, tx)
 }
 
+func (c *Local) BroadcastTxBatch(ctx context.Context, txs []types.Tx) (*ctypes.ResultBroadcastTxBatch, error) {
+	return core.BroadcastTxBatch(c.ctx, txs)
+}
+
 func (c *Local) UnconfirmedTxs(ctx context.Context, limit *int) (*ctypes.ResultUnconfirmedTxs, error) {
 	return core.UnconfirmedTxs(c.ctx, limit)
 }
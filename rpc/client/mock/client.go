@@ -111,6 +111,10 @@ func (c Client) BroadcastTxSync(ctx context.Context, tx types.Tx) (*ctypes.Resul
 	return core.BroadcastTxSync(&rpctypes.Context{}, tx)
 }
 
+func (c Client) BroadcastTxBatch(ctx context.Context, txs []types.Tx) (*ctypes.ResultBroadcastTxBatch, error) {
+	return core.BroadcastTxBatch(&rpctypes.Context{}, txs)
+}
+
 func (c Client) CheckTx(ctx context.Context, tx types.Tx) (*ctypes.ResultCheckTx, error) {
 	return core.CheckTx(&rpctypes.Context{}, tx)
 }
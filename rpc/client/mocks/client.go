@@ -252,6 +252,29 @@ func (_m *Client) BroadcastTxAsync(_a0 context.Context, _a1 types.Tx) (*coretype
 	return r0, r1
 }
 
+// BroadcastTxBatch provides a mock function with given fields: _a0, _a1
+func (_m *Client) BroadcastTxBatch(_a0 context.Context, _a1 []types.Tx) (*coretypes.ResultBroadcastTxBatch, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 *coretypes.ResultBroadcastTxBatch
+	if rf, ok := ret.Get(0).(func(context.Context, []types.Tx) *coretypes.ResultBroadcastTxBatch); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*coretypes.ResultBroadcastTxBatch)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, []types.Tx) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // BroadcastTxCommit provides a mock function with given fields: _a0, _a1
 func (_m *Client) BroadcastTxCommit(_a0 context.Context, _a1 types.Tx) (*coretypes.ResultBroadcastTxCommit, error) {
 	ret := _m.Called(_a0, _a1)
@@ -350,6 +350,27 @@ func TestBroadcastTxSync(t *testing.T) {
 	}
 }
 
+func TestBroadcastTxBatch(t *testing.T) {
+	mempool := node.Mempool()
+	for i, c := range GetClients() {
+		_, _, tx1 := MakeTxKV()
+		_, _, tx2 := MakeTxKV()
+		// the repeated tx is refused by the mempool, the others are admitted
+		res, err := c.BroadcastTxBatch(context.Background(), []types.Tx{tx1, tx1, tx2})
+		require.NoError(t, err, "%d", i)
+		require.Len(t, res.CheckTxs, 3)
+		require.Equal(t, abci.CodeTypeOK, res.CheckTxs[0].Code)
+		require.Equal(t, mempl.CodeTypeRejected, res.CheckTxs[1].Code)
+		require.Equal(t, mempl.Codespace, res.CheckTxs[1].Codespace)
+		require.Equal(t, abci.CodeTypeOK, res.CheckTxs[2].Code)
+		require.Equal(t, 2, mempool.Size())
+		mempool.Flush()
+
+		_, err = c.BroadcastTxBatch(context.Background(), make([]types.Tx, 1001))
+		require.Error(t, err, "%d", i)
+	}
+}
+
 func TestBroadcastTxCommit(t *testing.T) {
 	require := require.New(t)
 
@@ -1,6 +1,8 @@
 package core
 
 import (
+	"errors"
+
 	ctypes "github.com/tendermint/tendermint/rpc/core/types"
 	rpctypes "github.com/tendermint/tendermint/rpc/jsonrpc/types"
 )
@@ -10,3 +12,18 @@ func UnsafeFlushMempool(ctx *rpctypes.Context) (*ctypes.ResultUnsafeFlushMempool
 	GetEnvironment().Mempool.Flush()
 	return &ctypes.ResultUnsafeFlushMempool{}, nil
 }
+
+// UnsafeSetMempoolExperiment sets the experiment label with which the mempool
+// counts admitted transactions. An empty label ends the experiment. The number
+// of distinct labels is capped, further ones are counted as "other".
+func UnsafeSetMempoolExperiment(
+	ctx *rpctypes.Context,
+	label string,
+) (*ctypes.ResultUnsafeSetMempoolExperiment, error) {
+	mp, ok := GetEnvironment().Mempool.(interface{ SetExperimentLabel(string) })
+	if !ok {
+		return nil, errors.New("mempool does not support experiment labels")
+	}
+	mp.SetExperimentLabel(label)
+	return &ctypes.ResultUnsafeSetMempoolExperiment{}, nil
+}
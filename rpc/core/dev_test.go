@@ -0,0 +1,49 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/go-kit/kit/metrics/prometheus"
+	stdprometheus "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+
+	abci "github.com/tendermint/tendermint/abci/types"
+	cfg "github.com/tendermint/tendermint/config"
+	"github.com/tendermint/tendermint/libs/log"
+	mempl "github.com/tendermint/tendermint/mempool"
+	"github.com/tendermint/tendermint/mempool/cat"
+	rpctypes "github.com/tendermint/tendermint/rpc/jsonrpc/types"
+	"github.com/tendermint/tendermint/types"
+)
+
+func TestUnsafeSetMempoolExperiment(t *testing.T) {
+	env := GetEnvironment()
+	env.Logger = log.TestingLogger()
+
+	// only the CAT mempool supports experiment labels
+	env.Mempool = newTestMempool(t, cfg.TestMempoolConfig())
+	_, err := UnsafeSetMempoolExperiment(&rpctypes.Context{}, "on")
+	require.Error(t, err)
+
+	admitted := stdprometheus.NewCounterVec(stdprometheus.CounterOpts{Name: "admitted_txs"}, []string{"experiment"})
+	metrics := mempl.NopMetrics()
+	metrics.AdmittedTxs = prometheus.NewCounter(admitted)
+	env.Mempool = cat.NewTxPool(env.Logger, cfg.TestMempoolConfig(), newTestAppConn(t), 0, cat.WithMetrics(metrics))
+
+	res, err := UnsafeSetMempoolExperiment(&rpctypes.Context{}, "on")
+	require.NoError(t, err)
+	require.NotNil(t, res)
+	rsp, err := checkTxSync(&rpctypes.Context{}, types.Tx("a=1"))
+	require.NoError(t, err)
+	require.Equal(t, abci.CodeTypeOK, rsp.Code)
+
+	_, err = UnsafeSetMempoolExperiment(&rpctypes.Context{}, "")
+	require.NoError(t, err)
+	rsp, err = checkTxSync(&rpctypes.Context{}, types.Tx("b=1"))
+	require.NoError(t, err)
+	require.Equal(t, abci.CodeTypeOK, rsp.Code)
+
+	require.Equal(t, float64(1), testutil.ToFloat64(admitted.WithLabelValues("on")))
+	require.Equal(t, float64(1), testutil.ToFloat64(admitted.WithLabelValues("")))
+}
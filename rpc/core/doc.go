@@ -32,6 +32,7 @@ Endpoints that require arguments:
 /block?height=_
 /blockchain?minHeight=_&maxHeight=_
 /broadcast_tx_async?tx=_
+/broadcast_tx_batch?txs=_
 /broadcast_tx_commit?tx=_
 /broadcast_tx_sync?tx=_
 /commit?height=_
@@ -39,6 +40,7 @@ Endpoints that require arguments:
 /dial_persistent_peers?persistent_peers=_
 /subscribe?event=_
 /tx?hash=_&prove=_
+/unsafe_set_mempool_experiment?label=_
 /unsubscribe?event=_
 ```
 */
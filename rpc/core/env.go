@@ -25,6 +25,10 @@ const (
 	defaultPerPage = 30
 	maxPerPage     = 100
 
+	// maxBroadcastTxBatch is the maximum number of txs in a BroadcastTxBatch
+	// request, so that one request can't hold up CheckTx for too long.
+	maxBroadcastTxBatch = 1000
+
 	// SubscribeTimeout is the maximum time we wait to subscribe for an event.
 	// must be less than the server's write timeout (see rpcserver.DefaultConfig)
 	SubscribeTimeout = 5 * time.Second
@@ -104,6 +108,7 @@ type Environment struct {
 	ConsensusReactor *consensus.Reactor
 	EventBus         *types.EventBus // thread safe
 	Mempool          mempl.Mempool
+	MempoolMetrics   *mempl.Metrics // optional
 
 	Logger log.Logger
 
@@ -113,6 +118,15 @@ type Environment struct {
 	genChunks []string
 }
 
+// mempoolMetrics returns the mempool metrics, or no-op metrics if none were
+// set.
+func (env *Environment) mempoolMetrics() *mempl.Metrics {
+	if env.MempoolMetrics == nil {
+		return mempl.NopMetrics()
+	}
+	return env.MempoolMetrics
+}
+
 //----------------------------------------------
 
 func validatePage(pagePtr *int, perPage, totalCount int) (int, error) {
@@ -59,6 +59,82 @@ func BroadcastTxSync(ctx *rpctypes.Context, tx types.Tx) (*ctypes.ResultBroadcas
 	}
 }
 
+// BroadcastTxBatch returns with the responses from CheckTx for each of the
+// txs, in the same order. Valid txs are added to the mempool even if others
+// in the batch are rejected. Once the mempool is full, the remaining txs are
+// not checked. Txs refused by the mempool rather than the application get a
+// response in the mempool codespace. Does not wait for DeliverTx results.
+// Batches are limited to maxBroadcastTxBatch txs.
+func BroadcastTxBatch(ctx *rpctypes.Context, txs []types.Tx) (*ctypes.ResultBroadcastTxBatch, error) {
+	if len(txs) > maxBroadcastTxBatch {
+		return nil, fmt.Errorf("batch of %d txs exceeds the maximum of %d", len(txs), maxBroadcastTxBatch)
+	}
+	metrics := GetEnvironment().mempoolMetrics()
+	metrics.BroadcastBatches.Add(1)
+	metrics.TxsPerBroadcastBatch.Observe(float64(len(txs)))
+
+	// the CAT mempool checks the batch itself
+	if mp, ok := GetEnvironment().Mempool.(interface {
+		CheckTxBatch(types.Txs, mempl.TxInfo) []*abci.ResponseCheckTx
+	}); ok {
+		return &ctypes.ResultBroadcastTxBatch{CheckTxs: mp.CheckTxBatch(txs, mempl.TxInfo{})}, nil
+	}
+
+	responses := make([]*abci.ResponseCheckTx, len(txs))
+	var errFull error
+	for i, tx := range txs {
+		if errFull != nil {
+			responses[i] = rejectedCheckTx(errFull)
+			continue
+		}
+		rsp, err := checkTxSync(ctx, tx)
+		switch {
+		case errors.As(err, &mempl.ErrMempoolIsFull{}):
+			errFull = err
+			responses[i] = rejectedCheckTx(err)
+		case err != nil:
+			responses[i] = rejectedCheckTx(err)
+		// the priority mempool drops a valid tx it has no room for and only
+		// reports it in the response
+		case rsp.Code == abci.CodeTypeOK && rsp.MempoolError != "":
+			errFull = errors.New(rsp.MempoolError)
+			responses[i] = rejectedCheckTx(errFull)
+		default:
+			responses[i] = rsp
+		}
+	}
+	return &ctypes.ResultBroadcastTxBatch{CheckTxs: responses}, nil
+}
+
+// checkTxSync adds tx to the mempool and waits for the response from CheckTx.
+func checkTxSync(ctx *rpctypes.Context, tx types.Tx) (*abci.ResponseCheckTx, error) {
+	resCh := make(chan *abci.Response, 1)
+	err := GetEnvironment().Mempool.CheckTx(tx, func(res *abci.Response) {
+		resCh <- res
+	}, mempl.TxInfo{})
+	if err != nil {
+		return nil, err
+	}
+
+	select {
+	case <-ctx.Context().Done():
+		return nil, fmt.Errorf("broadcast confirmation not received: %w", ctx.Context().Err())
+	case res := <-resCh:
+		return res.GetCheckTx(), nil
+	}
+}
+
+// rejectedCheckTx returns the response for a tx that the mempool refused with
+// the given error.
+func rejectedCheckTx(err error) *abci.ResponseCheckTx {
+	return &abci.ResponseCheckTx{
+		Code:         mempl.CodeTypeRejected,
+		Codespace:    mempl.Codespace,
+		Log:          err.Error(),
+		MempoolError: err.Error(),
+	}
+}
+
 // BroadcastTxCommit returns with the responses from CheckTx and DeliverTx.
 // More: https://docs.cometbft.com/v0.34/rpc/#/Tx/broadcast_tx_commit
 func BroadcastTxCommit(ctx *rpctypes.Context, tx types.Tx) (*ctypes.ResultBroadcastTxCommit, error) {
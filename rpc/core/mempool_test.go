@@ -0,0 +1,100 @@
+package core
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/go-kit/kit/metrics"
+	"github.com/go-kit/kit/metrics/generic"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tendermint/tendermint/abci/example/kvstore"
+	abci "github.com/tendermint/tendermint/abci/types"
+	cfg "github.com/tendermint/tendermint/config"
+	"github.com/tendermint/tendermint/libs/log"
+	mempl "github.com/tendermint/tendermint/mempool"
+	mempoolv1 "github.com/tendermint/tendermint/mempool/v1"
+	"github.com/tendermint/tendermint/proxy"
+	rpctypes "github.com/tendermint/tendermint/rpc/jsonrpc/types"
+	"github.com/tendermint/tendermint/types"
+)
+
+// recordingHistogram is a metrics.Histogram that keeps every observed value.
+type recordingHistogram struct {
+	mtx    sync.Mutex
+	values []float64
+}
+
+func (h *recordingHistogram) With(labelValues ...string) metrics.Histogram { return h }
+
+func (h *recordingHistogram) Observe(value float64) {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	h.values = append(h.values, value)
+}
+
+func newTestAppConn(t *testing.T) proxy.AppConnMempool {
+	appConn, err := proxy.NewLocalClientCreator(kvstore.NewApplication()).NewABCIClient()
+	require.NoError(t, err)
+	require.NoError(t, appConn.Start())
+	t.Cleanup(func() {
+		if err := appConn.Stop(); err != nil {
+			t.Error(err)
+		}
+	})
+	return appConn
+}
+
+func newTestMempool(t *testing.T, config *cfg.MempoolConfig) mempl.Mempool {
+	return mempoolv1.NewTxMempool(log.TestingLogger(), config, newTestAppConn(t), 0)
+}
+
+func TestBroadcastTxBatchMetrics(t *testing.T) {
+	batches := generic.NewCounter("broadcast_batches")
+	txsPerBatch := &recordingHistogram{}
+	env := GetEnvironment()
+	env.Logger = log.TestingLogger()
+	env.Mempool = newTestMempool(t, cfg.TestMempoolConfig())
+	env.MempoolMetrics = mempl.NopMetrics()
+	env.MempoolMetrics.BroadcastBatches = batches
+	env.MempoolMetrics.TxsPerBroadcastBatch = txsPerBatch
+	t.Cleanup(func() { env.MempoolMetrics = nil })
+
+	_, err := BroadcastTxBatch(&rpctypes.Context{}, []types.Tx{
+		types.Tx("a=1"),
+		types.Tx("b=2"),
+		types.Tx("c=3"),
+	})
+	require.NoError(t, err)
+	// rejected txs still count towards the size of the batch
+	_, err = BroadcastTxBatch(&rpctypes.Context{}, []types.Tx{types.Tx("a=1")})
+	require.NoError(t, err)
+
+	require.Equal(t, float64(2), batches.Value())
+	require.Equal(t, []float64{3, 1}, txsPerBatch.values)
+}
+
+func TestBroadcastTxBatchFullMempool(t *testing.T) {
+	config := cfg.TestMempoolConfig()
+	config.Size = 2
+	env := GetEnvironment()
+	env.Logger = log.TestingLogger()
+	env.Mempool = newTestMempool(t, config)
+
+	res, err := BroadcastTxBatch(&rpctypes.Context{}, []types.Tx{
+		types.Tx("a=1"),
+		types.Tx("b=2"),
+		types.Tx("c=3"), // dropped by the full mempool
+		types.Tx("d=4"), // not checked
+	})
+	require.NoError(t, err)
+	require.Len(t, res.CheckTxs, 4)
+	require.Equal(t, abci.CodeTypeOK, res.CheckTxs[0].Code)
+	require.Equal(t, abci.CodeTypeOK, res.CheckTxs[1].Code)
+	for _, rsp := range res.CheckTxs[2:] {
+		require.Equal(t, mempl.CodeTypeRejected, rsp.Code)
+		require.Equal(t, mempl.Codespace, rsp.Codespace)
+		require.Contains(t, rsp.MempoolError, "mempool is full")
+	}
+	require.Equal(t, 2, env.Mempool.Size())
+}
@@ -202,6 +202,11 @@ type ResultBroadcastTxCommit struct {
 	Height    int64                  `json:"height"`
 }
 
+// CheckTx results of a batch of txs, in the order of the batch
+type ResultBroadcastTxBatch struct {
+	CheckTxs []*abci.ResponseCheckTx `json:"check_txs"`
+}
+
 // ResultCheckTx wraps abci.ResponseCheckTx.
 type ResultCheckTx struct {
 	abci.ResponseCheckTx
@@ -254,11 +259,12 @@ type ResultBroadcastEvidence struct {
 
 // empty results
 type (
-	ResultUnsafeFlushMempool struct{}
-	ResultUnsafeProfile      struct{}
-	ResultSubscribe          struct{}
-	ResultUnsubscribe        struct{}
-	ResultHealth             struct{}
+	ResultUnsafeFlushMempool         struct{}
+	ResultUnsafeSetMempoolExperiment struct{}
+	ResultUnsafeProfile              struct{}
+	ResultSubscribe                  struct{}
+	ResultUnsubscribe                struct{}
+	ResultHealth                     struct{}
 )
 
 // Event data from a subscription
@@ -398,8 +398,10 @@ func createMempoolAndMempoolReactor(config *cfg.Config, proxyApp proxy.AppConns,
 		reactor, err := mempoolv2.NewReactor(
 			mp,
 			&mempoolv2.ReactorOptions{
-				ListenOnly: !config.Mempool.Broadcast,
-				MaxTxSize:  config.Mempool.MaxTxBytes,
+				ListenOnly:          !config.Mempool.Broadcast,
+				MaxTxSize:           config.Mempool.MaxTxBytes,
+				MaxSeenTxJitter:     config.Mempool.MaxSeenTxJitter,
+				DisableSeenTxJitter: config.Mempool.DisableSeenTxJitter,
 			},
 		)
 		if err != nil {